@@ -0,0 +1,119 @@
+package subscript
+
+import (
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// SubscriptAsciiDocRenderer renders Subscript nodes to AsciiDoc ("H~2~O"), for a goldmark renderer that
+// produces AsciiDoc. The extension registers it with WithOutputFormat(OutputAsciiDoc).
+//
+// AsciiDoc subscripts are unconstrained, so they can be written inside words, but their content cannot
+// hold spaces or formatting marks: these are written as attribute references ("{sp}", "{asterisk}",
+// "{tilde}", ...) or passthroughs ("pass:[_]"). After "]", which would turn the subscript into a role, or
+// after a node that is not text, the subscript is preceded by "{empty}". Content that is not plain text
+// (see WithInlineMarkdown) is left to the other renderers between the tildes.
+type SubscriptAsciiDocRenderer struct{}
+
+// NewSubscriptAsciiDocRenderer returns a new SubscriptAsciiDocRenderer.
+func NewSubscriptAsciiDocRenderer() renderer.NodeRenderer {
+	return &SubscriptAsciiDocRenderer{}
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *SubscriptAsciiDocRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindSubscript, r.renderSubscript)
+}
+
+func (r *SubscriptAsciiDocRenderer) renderSubscript(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		if before, _ := adjacentRunes(n, source); before == 0 || before == ']' || before == '\\' {
+			_, _ = w.WriteString("{empty}")
+		}
+	}
+	if !isTextOnly(n) {
+		_ = w.WriteByte('~')
+		return ast.WalkContinue, nil
+	}
+	if entering {
+		_ = w.WriteByte('~')
+		writeAsciiDoc(w, appendPlainText(nil, n, source))
+		_ = w.WriteByte('~')
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// asciiDocReplacements are the AsciiDoc replacements of characters that cannot be written as they are
+// in a subscript.
+var asciiDocReplacements = map[byte]string{
+	' ':  "{sp}",
+	'*':  "{asterisk}",
+	'`':  "{backtick}",
+	'^':  "{caret}",
+	'~':  "{tilde}",
+	'+':  "{plus}",
+	'[':  "{startsb}",
+	']':  "{endsb}",
+	'\\': "{backslash}",
+	'{':  "pass:[{]",
+	'_':  "pass:[_]",
+	'#':  "pass:[#]",
+}
+
+// writeAsciiDoc writes s for use in an AsciiDoc subscript.
+func writeAsciiDoc(w util.BufWriter, s []byte) {
+	for _, b := range s {
+		if replacement, ok := asciiDocReplacements[b]; ok {
+			_, _ = w.WriteString(replacement)
+		} else {
+			_ = w.WriteByte(b)
+		}
+	}
+}
+
+// adjacentRunes returns the characters written directly before and after n: a space at the start or end
+// of a block, a newline next to a line break and 0 next to a node that is not text.
+func adjacentRunes(n ast.Node, source []byte) (before, after rune) {
+	before, after = ' ', ' '
+	switch prev := n.PreviousSibling().(type) {
+	case nil:
+		if n.Parent() != nil && n.Parent().Type() == ast.TypeInline {
+			before = 0
+		}
+	case *ast.Text:
+		if prev.SoftLineBreak() || prev.HardLineBreak() {
+			before = '\n'
+		} else if value := prev.Segment.Value(source); len(value) > 0 {
+			before, _ = utf8.DecodeLastRune(value)
+		}
+	case *ast.String:
+		if len(prev.Value) > 0 {
+			before, _ = utf8.DecodeLastRune(prev.Value)
+		}
+	default:
+		before = 0
+	}
+	switch next := n.NextSibling().(type) {
+	case nil:
+		if n.Parent() != nil && n.Parent().Type() == ast.TypeInline {
+			after = 0
+		}
+	case *ast.Text:
+		if value := next.Segment.Value(source); len(value) > 0 {
+			after, _ = utf8.DecodeRune(value)
+		} else if next.SoftLineBreak() || next.HardLineBreak() {
+			after = '\n'
+		}
+	case *ast.String:
+		if len(next.Value) > 0 {
+			after, _ = utf8.DecodeRune(next.Value)
+		}
+	default:
+		after = 0
+	}
+	return before, after
+}
@@ -0,0 +1,16 @@
+package subscript
+
+import (
+	"testing"
+)
+
+func TestSubscriptAsciiDocRenderer(t *testing.T) {
+	testOutputFormat(t, NewSubscriptAsciiDocRenderer(), nil, []outputTestCase{
+		{`H~2~O and x~i+1~`, `H~2~O and x~i{plus}1~`},
+		{`T~max\ value~`, `T~max{sp}value~`},
+		{`x~a*b_c^d~`, `x~a{asterisk}bpass:[_]c{caret}d~`},
+		{`x~[a]\\~`, `x~{startsb}a{endsb}{backslash}~`},
+		{`[a]~2~`, `[a]{empty}~2~`},
+		{`*a*~2~`, `*a*{empty}~2~`},
+	})
+}
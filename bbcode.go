@@ -0,0 +1,58 @@
+package subscript
+
+import (
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// SubscriptBBCodeRenderer renders Subscript nodes to BBCode ("H~2~O" becomes "H[sub]2[/sub]O"), for a
+// goldmark renderer that produces BBCode for forums. The extension registers it with
+// WithOutputFormat(OutputBBCode).
+//
+// Square brackets in the content are written as the character references &#91; and &#93;, so they cannot
+// form tags. Content that is not plain text (see WithInlineMarkdown) is left to the other renderers
+// between the tags.
+type SubscriptBBCodeRenderer struct{}
+
+// NewSubscriptBBCodeRenderer returns a new SubscriptBBCodeRenderer.
+func NewSubscriptBBCodeRenderer() renderer.NodeRenderer {
+	return &SubscriptBBCodeRenderer{}
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *SubscriptBBCodeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindSubscript, r.renderSubscript)
+}
+
+func (r *SubscriptBBCodeRenderer) renderSubscript(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !isTextOnly(n) {
+		if entering {
+			_, _ = w.WriteString("[sub]")
+		} else {
+			_, _ = w.WriteString("[/sub]")
+		}
+		return ast.WalkContinue, nil
+	}
+	if entering {
+		_, _ = w.WriteString("[sub]")
+		writeBBCode(w, appendPlainText(nil, n, source))
+		_, _ = w.WriteString("[/sub]")
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// writeBBCode writes s for use in BBCode, escaping square brackets.
+func writeBBCode(w util.BufWriter, s []byte) {
+	for _, b := range s {
+		switch b {
+		case '[':
+			_, _ = w.WriteString("&#91;")
+		case ']':
+			_, _ = w.WriteString("&#93;")
+		default:
+			_ = w.WriteByte(b)
+		}
+	}
+}
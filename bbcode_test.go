@@ -0,0 +1,13 @@
+package subscript
+
+import (
+	"testing"
+)
+
+func TestSubscriptBBCodeRenderer(t *testing.T) {
+	testOutputFormat(t, NewSubscriptBBCodeRenderer(), nil, []outputTestCase{
+		{`H~2~O and x~i+1~`, `H[sub]2[/sub]O and x[sub]i+1[/sub]`},
+		{`T~max\ value~`, `T[sub]max value[/sub]`},
+		{`x~[/sub]~`, `x[sub]&#91;/sub&#93;[/sub]`},
+	})
+}
@@ -0,0 +1,78 @@
+package subscript
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// chemistryDocument returns a document of n paragraphs full of chemical formulas.
+func chemistryDocument(n int) []byte {
+	paragraph := "Glucose (C~6~H~12~O~6~) is oxidized to CO~2~ and H~2~O, releasing energy as ATP. " +
+		"Sulfuric acid (H~2~SO~4~) reacts with NaOH to give Na~2~SO~4~ and water; ~~not~~ all of it " +
+		"dissociates. The rate constant k~obs~ depends on T~max\\ value~ and on [Fe(CN)~6~].\n\n"
+	return []byte(strings.Repeat(paragraph, n))
+}
+
+func benchmarkConvert(b *testing.B, md goldmark.Markdown, source []byte) {
+	var buf bytes.Buffer
+	b.SetBytes(int64(len(source)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if err := md.Convert(source, &buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSubscript(b *testing.B) {
+	source := chemistryDocument(1000)
+	setups := []struct {
+		name string
+		md   goldmark.Markdown
+	}{
+		{"GFMOnly", goldmark.New(goldmark.WithExtensions(extension.GFM))},
+		{"Default", goldmark.New(goldmark.WithExtensions(extension.GFM, NewSubscript()))},
+		{"InlineMarkdown", goldmark.New(goldmark.WithExtensions(extension.GFM, NewSubscript(WithInlineMarkdown())))},
+		{"WhitespaceFlanking", goldmark.New(goldmark.WithExtensions(extension.GFM, NewSubscript(WithWhitespaceFlanking())))},
+		{"Tilde", goldmark.New(goldmark.WithExtensions(Tilde))},
+		{"Pandoc", goldmark.New(goldmark.WithExtensions(NewSubscript(DialectPandoc)))},
+	}
+	for _, setup := range setups {
+		b.Run(setup.name, func(b *testing.B) {
+			benchmarkConvert(b, setup.md, source)
+		})
+	}
+}
+
+func BenchmarkSubscriptTildes(b *testing.B) {
+	sources := []struct {
+		name   string
+		source []byte
+	}{
+		{"Runs", []byte("x" + strings.Repeat("~~~~ ", 10000))},
+		{"Separator", []byte("x " + strings.Repeat("~", 50000) + " y")},
+		{"Unclosed", []byte(strings.Repeat("a~b ", 10000))},
+		{"Spaces", []byte(strings.Repeat("x~a b~ ", 10000))},
+		{"DoubleTildes", []byte(strings.Repeat("x~a~~b ", 10000))},
+	}
+	setups := []struct {
+		name string
+		md   goldmark.Markdown
+	}{
+		{"Default", goldmark.New(goldmark.WithExtensions(extension.GFM, NewSubscript()))},
+		{"Pandoc", goldmark.New(goldmark.WithExtensions(NewSubscript(DialectPandoc)))},
+	}
+	for _, setup := range setups {
+		for _, src := range sources {
+			b.Run(setup.name+"/"+src.name, func(b *testing.B) {
+				benchmarkConvert(b, setup.md, src.source)
+			})
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package subscript
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// KindFormula is a NodeKind of the Formula node.
+var KindFormula = ast.NewNodeKind("Formula")
+
+// FormulaNode is a chemical formula written as a code span with the "ce:" prefix ("`ce:H2SO4`").
+//
+// Its children are the rendered formula: text, subscripts (*Node) for counts and superscripts
+// (*SuperscriptNode) for charges.
+type FormulaNode struct {
+	ast.BaseInline
+
+	// Formula is the formula as written, without the prefix.
+	Formula []byte
+}
+
+// Kind implements ast.Node.Kind.
+func (*FormulaNode) Kind() ast.NodeKind {
+	return KindFormula
+}
+
+// Dump implements ast.Node.Dump.
+func (n *FormulaNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{
+		"Formula": string(n.Formula),
+	}, nil)
+}
+
+// NewFormulaNode returns a new Formula node for the given formula.
+func NewFormulaNode(formula []byte) *FormulaNode {
+	n := &FormulaNode{
+		Formula: formula,
+	}
+	appendFormula(n, formula)
+	return n
+}
+
+// formulaPrefix marks a code span as a chemical formula.
+var formulaPrefix = []byte("ce:")
+
+// chemistryTransformer replaces code spans starting with "ce:" by Formula nodes.
+type chemistryTransformer struct{}
+
+// NewChemistryTransformer returns an ASTTransformer that replaces code spans starting with "ce:" by
+// Formula nodes, so "`ce:C6H12O6`" is rendered as "C<sub>6</sub>H<sub>12</sub>O<sub>6</sub>".
+func NewChemistryTransformer() parser.ASTTransformer {
+	return chemistryTransformer{}
+}
+
+// Transform implements parser.ASTTransformer.
+func (chemistryTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	var spans []*ast.CodeSpan
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if span, ok := n.(*ast.CodeSpan); ok && entering {
+			spans = append(spans, span)
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	for _, span := range spans {
+		content := appendNodeText(nil, span, source)
+		if !bytes.HasPrefix(content, formulaPrefix) {
+			continue
+		}
+		formula := bytes.TrimSpace(content[len(formulaPrefix):])
+		if len(formula) == 0 {
+			continue
+		}
+		span.Parent().ReplaceChild(span.Parent(), span, NewFormulaNode(formula))
+	}
+}
+
+// appendFormula appends the rendered formula to n.
+//
+// Digits directly after an element symbol or a closing bracket are counts and become subscripts; other
+// digits are coefficients and stay text. A charge is written with a caret ("SO4^2-", "Fe^3+") or as a
+// trailing sign ("Na+", "Cl-") and becomes a superscript. A dot or asterisk between two parts of a formula
+// is a hydrate dot ("CuSO4.5H2O") and is rendered as a middle dot.
+func appendFormula(n ast.Node, formula []byte) {
+	var text []byte
+	flush := func() {
+		if len(text) > 0 {
+			n.AppendChild(n, ast.NewString(text))
+			text = nil
+		}
+	}
+	script := func(node ast.Node, value []byte) {
+		flush()
+		node.AppendChild(node, ast.NewString(value))
+		n.AppendChild(n, node)
+	}
+
+	// counted is whether digits at the current position are a count
+	counted := false
+	for i := 0; i < len(formula); {
+		c := formula[i]
+		switch {
+		case c >= 'A' && c <= 'Z':
+			j := i + 1
+			for j < len(formula) && formula[j] >= 'a' && formula[j] <= 'z' {
+				j++
+			}
+			text = append(text, formula[i:j]...)
+			counted = true
+			i = j
+			continue
+		case isDigit(c) && counted:
+			j := i + 1
+			for j < len(formula) && isDigit(formula[j]) {
+				j++
+			}
+			script(NewSubscriptNode(), formula[i:j])
+			i = j
+			continue
+		case c == '^':
+			if j := chargeEnd(formula, i+1); j > i+1 {
+				script(NewSuperscriptNode(), formula[i+1:j])
+				counted = false
+				i = j
+				continue
+			}
+		case (c == '+' || c == '-') && counted && (i+1 == len(formula) || formula[i+1] == ' '):
+			script(NewSuperscriptNode(), formula[i:i+1])
+			counted = false
+			i++
+			continue
+		case (c == '.' || c == '*') && counted && i+1 < len(formula) &&
+			(isDigit(formula[i+1]) || formula[i+1] >= 'A' && formula[i+1] <= 'Z'):
+			text = append(text, "·"...)
+			counted = false
+			i++
+			continue
+		case c == ')' || c == ']':
+			text = append(text, c)
+			counted = true
+			i++
+			continue
+		}
+		text = append(text, c)
+		counted = false
+		i++
+	}
+	flush()
+}
+
+// chargeEnd returns the end of the charge ("2-", "+", "3+") starting at formula[i], or i if there is none.
+func chargeEnd(formula []byte, i int) int {
+	j := i
+	for j < len(formula) && isDigit(formula[j]) {
+		j++
+	}
+	if j < len(formula) && (formula[j] == '+' || formula[j] == '-') {
+		return j + 1
+	}
+	return i
+}
+
+// FormulaHTMLRenderer renders Formula nodes to HTML <span class="chem"> elements.
+//
+// Counts and charges inside a formula are rendered by the subscript and superscript renderers.
+type FormulaHTMLRenderer struct {
+	html.Config
+	attributeFilter util.BytesFilter
+}
+
+// NewFormulaHTMLRenderer returns a new FormulaHTMLRenderer with the given options.
+func NewFormulaHTMLRenderer(opts ...SubscriptOption) renderer.NodeRenderer {
+	return newFormulaHTMLRenderer(newConfig(opts))
+}
+
+func newFormulaHTMLRenderer(config Config) *FormulaHTMLRenderer {
+	r := &FormulaHTMLRenderer{}
+	r.Config, r.attributeFilter = newHTMLConfig(config)
+	return r
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *FormulaHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindFormula, r.renderFormula)
+}
+
+func (r *FormulaHTMLRenderer) renderFormula(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString(`<span class="chem"`)
+		if n.Attributes() != nil {
+			html.RenderAttributes(w, n, r.attributeFilter)
+		}
+		_ = w.WriteByte('>')
+	} else {
+		_, _ = w.WriteString("</span>")
+	}
+	return ast.WalkContinue, nil
+}
@@ -0,0 +1,199 @@
+package subscript
+
+import (
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/testutil"
+	"github.com/yuin/goldmark/text"
+)
+
+func TestChemistry(t *testing.T) {
+	mdTest := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			NewSubscript(WithChemistry()),
+		),
+	)
+
+	testCases := []TestCase{
+		{
+			desc: "Chemistry: counts become subscripts",
+			md:   "Glucose is `ce:C6H12O6`",
+			html: `<p>Glucose is <span class="chem">C<sub>6</sub>H<sub>12</sub>O<sub>6</sub></span></p>`,
+		},
+		{
+			desc: "Chemistry: brackets",
+			md:   "`ce:Ca3(PO4)2` and `ce:[Fe(CN)6]^3-`",
+			html: `<p><span class="chem">Ca<sub>3</sub>(PO<sub>4</sub>)<sub>2</sub></span> and ` +
+				`<span class="chem">[Fe(CN)<sub>6</sub>]<sup>3-</sup></span></p>`,
+		},
+		{
+			desc: "Chemistry: charges",
+			md:   "`ce:SO4^2-`, `ce:Fe^3+`, `ce:Na+`, `ce:Cl-` and `ce:NH4+`",
+			html: `<p><span class="chem">SO<sub>4</sub><sup>2-</sup></span>, <span class="chem">Fe<sup>3+</sup></span>, ` +
+				`<span class="chem">Na<sup>+</sup></span>, <span class="chem">Cl<sup>-</sup></span> and ` +
+				`<span class="chem">NH<sub>4</sub><sup>+</sup></span></p>`,
+		},
+		{
+			desc: "Chemistry: hydrate dot",
+			md:   "`ce:CuSO4.5H2O` and `ce:CaSO4*2H2O`",
+			html: `<p><span class="chem">CuSO<sub>4</sub>·5H<sub>2</sub>O</span> and ` +
+				`<span class="chem">CaSO<sub>4</sub>·2H<sub>2</sub>O</span></p>`,
+		},
+		{
+			desc: "Chemistry: coefficients stay text",
+			md:   "`ce:2H2 + O2`",
+			html: `<p><span class="chem">2H<sub>2</sub> + O<sub>2</sub></span></p>`,
+		},
+		{
+			desc: "Chemistry: content is escaped",
+			md:   "`ce:a<b`",
+			html: `<p><span class="chem">a&lt;b</span></p>`,
+		},
+		{
+			desc: "Chemistry: other code spans are untouched",
+			md:   "`H2O` and `ce:`",
+			html: `<p><code>H2O</code> and <code>ce:</code></p>`,
+		},
+		{
+			desc: "Chemistry: subscripts still work",
+			md:   "H~2~O and `ce:H2O`",
+			html: `<p>H<sub>2</sub>O and <span class="chem">H<sub>2</sub>O</span></p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(mdTest, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+}
+
+func TestChemBlock(t *testing.T) {
+	mdTest := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			NewSubscript(WithChemBlocks()),
+		),
+	)
+
+	testCases := []TestCase{
+		{
+			desc: "Chem block: reactions",
+			md:   "```chem\n2H2 + O2 -> 2H2O\n\n  N2 + 3H2 <=> 2NH3\n```",
+			html: `<div class="chem-block">
+<div class="chem-reaction"><span class="chem-side">2<span class="chem">H<sub>2</sub></span> <span class="chem-op">+</span> ` +
+				`<span class="chem">O<sub>2</sub></span></span> <span class="chem-arrow">→</span> ` +
+				`<span class="chem-side">2<span class="chem">H<sub>2</sub>O</span></span></div>
+<div class="chem-reaction"><span class="chem-side"><span class="chem">N<sub>2</sub></span> <span class="chem-op">+</span> ` +
+				`3<span class="chem">H<sub>2</sub></span></span> <span class="chem-arrow">⇌</span> ` +
+				`<span class="chem-side">2<span class="chem">NH<sub>3</sub></span></span></div>
+</div>`,
+		},
+		{
+			desc: "Chem block: charges",
+			md:   "```chem\nFe^3+ + 3OH- -> Fe(OH)3\n```",
+			html: `<div class="chem-block">
+<div class="chem-reaction"><span class="chem-side"><span class="chem">Fe<sup>3+</sup></span> <span class="chem-op">+</span> ` +
+				`3<span class="chem">OH<sup>-</sup></span></span> <span class="chem-arrow">→</span> ` +
+				`<span class="chem-side"><span class="chem">Fe(OH)<sub>3</sub></span></span></div>
+</div>`,
+		},
+		{
+			desc: "Chem block: electrons",
+			md:   "```chem\nFe^3+ + e- -> Fe^2+\nO2 + 4H+ + 4e^- -> 2H2O\n```",
+			html: `<div class="chem-block">
+<div class="chem-reaction"><span class="chem-side"><span class="chem">Fe<sup>3+</sup></span> <span class="chem-op">+</span> ` +
+				`e<sup>−</sup></span> <span class="chem-arrow">→</span> ` +
+				`<span class="chem-side"><span class="chem">Fe<sup>2+</sup></span></span></div>
+<div class="chem-reaction"><span class="chem-side"><span class="chem">O<sub>2</sub></span> <span class="chem-op">+</span> ` +
+				`4<span class="chem">H<sup>+</sup></span> <span class="chem-op">+</span> 4e<sup>−</sup></span> ` +
+				`<span class="chem-arrow">→</span> <span class="chem-side">2<span class="chem">H<sub>2</sub>O</span></span></div>
+</div>`,
+		},
+		{
+			desc: "Chem block: nested and escaped",
+			md:   "> ```chem\n> a<b\n> ```",
+			html: `<blockquote>
+<div class="chem-block">
+<div class="chem-reaction"><span class="chem-side"><span class="chem">a&lt;b</span></span></div>
+</div>
+</blockquote>`,
+		},
+		{
+			desc: "Chem block: other fenced blocks are untouched",
+			md:   "```go\nH2O -> x\n```",
+			html: `<pre><code class="language-go">H2O -&gt; x
+</code></pre>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(mdTest, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+}
+
+func TestChemistryDisabled(t *testing.T) {
+	mdTest := goldmark.New(
+		goldmark.WithExtensions(
+			NewSubscript(),
+		),
+	)
+
+	testutil.DoTestCase(mdTest, testutil.MarkdownTestCase{
+		Description: "Chemistry is opt-in",
+		Markdown:    "`ce:H2O`",
+		Expected:    `<p><code>ce:H2O</code></p>`,
+	}, t)
+
+	mdChemistry := goldmark.New(
+		goldmark.WithExtensions(
+			NewSubscript(WithChemistry()),
+		),
+	)
+
+	testutil.DoTestCase(mdChemistry, testutil.MarkdownTestCase{
+		Description: "Chem blocks are a separate opt-in",
+		Markdown:    "```chem\n2H2 + O2 -> 2H2O\n```",
+		Expected: `<pre><code class="language-chem">2H2 + O2 -&gt; 2H2O
+</code></pre>`,
+	}, t)
+}
+
+func TestFormulaNode(t *testing.T) {
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			NewSubscript(WithChemistry()),
+		),
+	)
+	source := []byte("`ce: H2SO4 `")
+	doc := md.Parser().Parse(text.NewReader(source))
+	formula, ok := doc.FirstChild().FirstChild().(*FormulaNode)
+	if !ok {
+		t.Fatalf("expected a Formula node, got %T", doc.FirstChild().FirstChild())
+	}
+	if got := string(formula.Formula); got != "H2SO4" {
+		t.Errorf("Formula = %q, want %q", got, "H2SO4")
+	}
+	var subscripts int
+	for c := formula.FirstChild(); c != nil; c = c.NextSibling() {
+		if _, ok := c.(*Node); ok {
+			subscripts++
+		}
+	}
+	if subscripts != 2 {
+		t.Errorf("got %d subscripts, want 2", subscripts)
+	}
+}
@@ -0,0 +1,298 @@
+package subscript
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// tildeStateKey holds the *tildeState of the document being parsed.
+var tildeStateKey = parser.NewContextKey()
+
+// tildeState is the per-document state of the delimiter based subscript parsing.
+//
+// Parsers are shared by concurrent Convert calls, so everything that belongs to a single
+// document lives here, in the parser.Context of that document.
+type tildeState struct {
+	parser *subscriptParser
+	source []byte
+
+	// closer is the position of the closing tilde of a subscript opened by the
+	// scanning parser in inline markdown mode, or -1.
+	closer int
+
+	// subscript is the processor of delimiters that can open a subscript,
+	// other is the processor of delimiters that can only open a strikethrough.
+	subscript tildeDelimiterProcessor
+	other     tildeDelimiterProcessor
+
+	// match is the node kind CanOpenCloser decided on for the last matching pair,
+	// content is the content between that pair.
+	match   ast.NodeKind
+	content text.Segment
+
+	// last is the last single-tilde delimiter pushed by parseDelimiter in the current block.
+	last *parser.Delimiter
+
+	// rejected is the last closer whose content with some opener was not valid subscript content,
+	// rejectedAt the position of the offending character. Openers before that position are
+	// rejected without looking at the content again.
+	rejected   *parser.Delimiter
+	rejectedAt int
+
+	// nodes are the subscripts created by the delimiter processor in the current block.
+	nodes []matchedSubscript
+}
+
+// matchedSubscript is a subscript created by the delimiter processor and the raw content it spans.
+type matchedSubscript struct {
+	node    *Node
+	content text.Segment
+}
+
+// state returns the tildeState of the document being parsed, creating it if necessary.
+func (s *subscriptParser) state(block text.Reader, pc parser.Context) *tildeState {
+	source := block.Source()
+	if state, ok := pc.Get(tildeStateKey).(*tildeState); ok && state.parser == s && sameSource(state.source, source) {
+		return state
+	}
+	state := &tildeState{
+		parser: s,
+		source: source,
+		closer: -1,
+	}
+	state.subscript = tildeDelimiterProcessor{state: state, subscript: true}
+	state.other = tildeDelimiterProcessor{state: state}
+	pc.Set(tildeStateKey, state)
+	return state
+}
+
+// sameSource reports whether a and b are the same source buffer, so a parser.Context reused
+// for another document never sees the state of the previous one.
+func sameSource(a, b []byte) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// parseDelimiter parses a tilde with whitespace-only left/right-flanking rules.
+//
+// Every single tilde becomes a delimiter; which tildes form a subscript is decided by the
+// delimiter processor once the block has been parsed, the same way emphasis is resolved.
+// A tilde can open a span when it is not followed by whitespace and close a span when it is
+// not preceded by whitespace. Unlike emphasis, punctuation next to a tilde does not prevent it
+// from opening or closing, so "x~(2)~" and "x~*i*~" are subscripts.
+// Runs of two tildes become delimiters too when the extension owns strikethrough; otherwise
+// they are left to the strikethrough parser. Longer runs are never delimiters.
+func (s *subscriptParser) parseDelimiter(block text.Reader, pc parser.Context) ast.Node {
+	before := block.PrecendingCharacter()
+	line, segment := block.PeekLine()
+	if before == '~' {
+		return nil
+	}
+	length := tildeRun(line)
+	if length > 2 {
+		return literalRun(block, segment, length)
+	}
+	if length == 2 && !s.TildeMode.ownsStrikethrough() {
+		return nil
+	}
+	after := ' '
+	if len(line) > length {
+		after = util.ToRune(line, length)
+	}
+	state := s.state(block, pc)
+	canOpen := !s.isSpace(after)
+	processor := &state.other
+	if length == 1 && s.canOpenAfter(before) {
+		processor = &state.subscript
+	} else if length == 1 && !s.strikesInvalidPairs() {
+		// Nothing can be opened here, so ProcessDelimiters need not ask
+		canOpen = false
+	}
+	d := parser.NewDelimiter(canOpen, !s.isSpace(before), length, '~', processor)
+	d.Segment = segment.WithStop(segment.Start + length)
+	s.retireOpener(state, segment.Start, pc)
+	if length == 1 {
+		state.last = d
+	}
+	block.Advance(length)
+	pc.PushDelimiter(d)
+	return d
+}
+
+// retireOpener stops the last single tilde from opening a span when no span it could open ends at pos or later,
+// because the content up to pos already contains a character that a subscript cannot contain.
+//
+// Goldmark tries every opener before each closer, so retiring openers keeps paragraphs full of tildes from
+// taking quadratic time. A retired opener that cannot close a span either is turned into text right away.
+// Double tildes in between do not shield the last single tilde: without inline markdown a tilde is such a
+// character, so a run of two tildes retires it at the next delimiter ("x~a~~b x~").
+func (s *subscriptParser) retireOpener(state *tildeState, pos int, pc parser.Context) {
+	last := state.last
+	if last == nil || last.Parent() == nil || !last.CanOpen || last.OriginalLength != 1 || s.strikesInvalidPairs() {
+		return
+	}
+	if s.invalidIndex(state.source[last.Segment.Stop:pos]) < 0 {
+		return
+	}
+	last.CanOpen = false
+	if !last.CanClose {
+		pc.RemoveDelimiter(last)
+	}
+}
+
+// pushDelimiter pushes a single-tilde subscript delimiter onto the delimiter list.
+func (s *subscriptParser) pushDelimiter(
+	block text.Reader, segment text.Segment, pc parser.Context, canOpen, canClose bool) ast.Node {
+	d := parser.NewDelimiter(canOpen, canClose, 1, '~', &s.state(block, pc).subscript)
+	d.Segment = segment.WithStop(segment.Start + 1)
+	block.Advance(1)
+	pc.PushDelimiter(d)
+	return d
+}
+
+// CloseBlock implements parser.CloseBlocker.
+//
+// Subscripts created by the delimiter processor get their final content here, once all
+// delimiters of the block have been processed: raw content replaces whatever the other inline
+// parsers made of it, and in inline markdown mode escaped spaces are removed from the text nodes.
+func (s *subscriptParser) CloseBlock(parent ast.Node, block text.Reader, pc parser.Context) {
+	state, ok := pc.Get(tildeStateKey).(*tildeState)
+	if !ok || state.parser != s {
+		return
+	}
+	state.closer = -1
+	state.last = nil
+	state.rejected = nil
+	source := block.Source()
+	for _, m := range state.nodes {
+		if s.InlineMarkdown {
+			if s.EscapedSpaces {
+				removeSpaceEscapes(m.node, source)
+			}
+			continue
+		}
+		m.node.RemoveChildren(m.node)
+		s.appendContent(m.node, m.content, m.content.Value(source))
+	}
+	clear(state.nodes)
+	state.nodes = state.nodes[:0]
+}
+
+// removeSpaceEscapes splits the text nodes below n at escaped spaces, dropping each backslash.
+// Code spans and raw HTML are left untouched.
+func removeSpaceEscapes(n ast.Node, source []byte) {
+	for c := n.FirstChild(); c != nil; {
+		next := c.NextSibling()
+		switch t := c.(type) {
+		case *ast.Text:
+			value := t.Segment.Value(source)
+			for i := len(value) - 1; i > 0; i-- {
+				if value[i] == ' ' && isEscapedSpace(value, i) {
+					rest := ast.NewTextSegment(t.Segment.WithStart(t.Segment.Start + i))
+					rest.SetSoftLineBreak(t.SoftLineBreak())
+					rest.SetHardLineBreak(t.HardLineBreak())
+					t.SetSoftLineBreak(false)
+					t.SetHardLineBreak(false)
+					t.Segment = t.Segment.WithStop(t.Segment.Start + i - 1)
+					n.InsertAfter(n, t, rest)
+				}
+			}
+		case *ast.CodeSpan, *ast.RawHTML:
+		default:
+			removeSpaceEscapes(c, source)
+		}
+		c = next
+	}
+}
+
+// tildeDelimiterProcessor pairs tilde delimiters.
+//
+// Only runs of the same length are paired. A pair of double tildes is always a strikethrough.
+// A pair of single tildes becomes a subscript when its opener can open a subscript and the
+// content between them is valid subscript content. Any other pair of single tildes becomes
+// a strikethrough, as it would without this extension, except in TildeModePandoc or without
+// a strikethrough parser, where it is not a pair at all.
+type tildeDelimiterProcessor struct {
+	state     *tildeState
+	subscript bool
+}
+
+// IsDelimiter implements parser.DelimiterProcessor.IsDelimiter.
+func (p *tildeDelimiterProcessor) IsDelimiter(b byte) bool {
+	return b == '~'
+}
+
+// CanOpenCloser implements parser.DelimiterProcessor.CanOpenCloser.
+// Tilde delimiters are only ever paired with each other, never with the delimiters of Goldmark's
+// strikethrough parser.
+func (p *tildeDelimiterProcessor) CanOpenCloser(opener, closer *parser.Delimiter) bool {
+	c, ok := closer.Processor.(*tildeDelimiterProcessor)
+	if !ok || c.state != p.state || opener.OriginalLength != closer.OriginalLength {
+		return false
+	}
+	state := p.state
+	state.content = text.NewSegment(opener.Segment.Start+opener.OriginalLength, closer.Segment.Start)
+	switch {
+	case opener.OriginalLength != 1:
+		state.match = extast.KindStrikethrough
+	case p.subscript && state.isValidContent(opener, closer) && !state.spansLinkLabel(opener, closer):
+		state.match = KindSubscript
+	case !state.parser.strikesInvalidPairs():
+		return false
+	default:
+		state.match = extast.KindStrikethrough
+	}
+	return true
+}
+
+// isValidContent reports whether state.content, the content between opener and closer, is valid subscript content.
+//
+// Goldmark tries every opener before a closer until one matches. Content that is invalid for an opener is
+// invalid for all openers before it too, so the first invalid character found for a closer is remembered.
+func (state *tildeState) isValidContent(opener, closer *parser.Delimiter) bool {
+	if closer == state.rejected && opener.Segment.Start < state.rejectedAt {
+		return false
+	}
+	content := state.content.Value(state.source)
+	if len(content) == 0 {
+		return false
+	}
+	if i := state.parser.invalidIndex(content); i >= 0 {
+		state.rejected = closer
+		state.rejectedAt = state.content.Start + i
+		return false
+	}
+	return true
+}
+
+// spansLinkLabel reports whether an unclosed link label ("[") lies between opener and closer.
+//
+// Without inline markdown, CloseBlock replaces the children of a subscript by its raw content. Goldmark's
+// link parser closes the block after that and turns its unclosed labels back into text, which fails for a
+// label that is no longer in the tree, so such pairs do not become subscripts ("x~a[~").
+func (state *tildeState) spansLinkLabel(opener, closer *parser.Delimiter) bool {
+	if state.parser.InlineMarkdown || bytes.IndexByte(state.content.Value(state.source), '[') < 0 {
+		return false
+	}
+	for c := opener.NextSibling(); c != nil && c != ast.Node(closer); c = c.NextSibling() {
+		if c.Kind().String() == "LinkLabelState" {
+			return true
+		}
+	}
+	return false
+}
+
+// OnMatch implements parser.DelimiterProcessor.OnMatch.
+func (p *tildeDelimiterProcessor) OnMatch(consumes int) ast.Node {
+	state := p.state
+	if state.match != KindSubscript {
+		return extast.NewStrikethrough()
+	}
+	node := NewSubscriptNode()
+	state.nodes = append(state.nodes, matchedSubscript{node: node, content: state.content})
+	return node
+}
@@ -0,0 +1,75 @@
+package subscript
+
+// DialectPandoc follows Pandoc's subscript and strikeout extensions.
+//
+// A subscript can start anywhere, may contain backslash-escaped spaces and inline markdown, but no
+// unescaped spaces. Two tildes are a strikethrough; a pair of single tildes that cannot be a subscript
+// is literal text.
+func DialectPandoc(c *Config) {
+	c.AllowLineStart = true
+	c.AllowAfterWhitespace = true
+	c.EscapedSpaces = true
+	c.InlineMarkdown = true
+	c.WhitespaceFlanking = true
+	c.TildeMode = TildeModePandoc
+}
+
+// DialectMarkdownItSub follows markdown-it-sub together with markdown-it's strikethrough rule.
+//
+// A subscript can start anywhere and may contain backslash-escaped spaces, but no unescaped spaces, and
+// its content is plain text. Two tildes are a strikethrough; a pair of single tildes that cannot be a
+// subscript is literal text.
+func DialectMarkdownItSub(c *Config) {
+	c.AllowLineStart = true
+	c.AllowAfterWhitespace = true
+	c.EscapedSpaces = true
+	c.InlineMarkdown = false
+	c.WhitespaceFlanking = true
+	c.TildeMode = TildeModePandoc
+}
+
+// DialectGFMSafe keeps subscripts close to what GitHub renders as a strikethrough.
+//
+// A subscript must follow a non-whitespace character on the same line and cannot contain spaces at
+// all, since GitHub does not treat a backslash before a space as an escape, so "H~2~O" is a subscript
+// where GitHub shows a strikethrough of the same text. Tildes that do not form a subscript are left to
+// Goldmark's strikethrough extension. The subscript parser still reaches each tilde before the
+// strikethrough parser, so tildes that GitHub pairs differently render differently: "~H~2~O~" is
+// "<del>H<sub>2</sub>O</del>" here and "<del>H</del>2<del>O</del>" on GitHub, and "a~b c~d~e" is
+// "a~b c<sub>d</sub>e" here and "a<del>b c</del>d~e" on GitHub.
+func DialectGFMSafe(c *Config) {
+	c.AllowLineStart = false
+	c.AllowAfterWhitespace = false
+	c.EscapedSpaces = false
+	c.InlineMarkdown = false
+	c.WhitespaceFlanking = false
+	c.TildeMode = TildeModeSubscript
+}
+
+// DialectMultiMarkdown follows MultiMarkdown 6.
+//
+// A subscript can start anywhere, its content is plain text and cannot contain spaces. MultiMarkdown
+// has no tilde strikethrough, so double tildes are left to Goldmark's strikethrough extension, if it
+// is installed.
+func DialectMultiMarkdown(c *Config) {
+	c.AllowLineStart = true
+	c.AllowAfterWhitespace = true
+	c.EscapedSpaces = false
+	c.InlineMarkdown = false
+	c.WhitespaceFlanking = false
+	c.TildeMode = TildeModeSubscript
+}
+
+// DialectObsidian keeps Obsidian's tilde rules and adds subscripts where Obsidian shows literal tildes.
+//
+// Obsidian renders "~~text~~" as a strikethrough and leaves single tildes alone. With this preset a pair
+// of single tildes directly after a non-whitespace character ("H~2~O") becomes a subscript, and every
+// other pair of single tildes stays literal text. Subscripts cannot contain spaces.
+func DialectObsidian(c *Config) {
+	c.AllowLineStart = false
+	c.AllowAfterWhitespace = false
+	c.EscapedSpaces = false
+	c.InlineMarkdown = false
+	c.WhitespaceFlanking = true
+	c.TildeMode = TildeModePandoc
+}
@@ -0,0 +1,246 @@
+package subscript
+
+import (
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/testutil"
+)
+
+func doDialectTestCases(t *testing.T, md goldmark.Markdown, testCases []TestCase) {
+	t.Helper()
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(md, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+}
+
+func TestDialectPandoc(t *testing.T) {
+	mdTest := goldmark.New(
+		goldmark.WithExtensions(
+			NewSubscript(DialectPandoc),
+		),
+	)
+
+	doDialectTestCases(t, mdTest, []TestCase{
+		{
+			desc: "Pandoc: subscript",
+			md:   `H~2~O is a liquid.`,
+			html: `<p>H<sub>2</sub>O is a liquid.</p>`,
+		},
+		{
+			desc: "Pandoc: strikeout",
+			md:   `This ~~is deleted text.~~`,
+			html: `<p>This <del>is deleted text.</del></p>`,
+		},
+		{
+			desc: "Pandoc: subscript at beginning of line",
+			md:   `~2~O`,
+			html: `<p><sub>2</sub>O</p>`,
+		},
+		{
+			desc: "Pandoc: subscript after whitespace",
+			md:   `log ~2~ n`,
+			html: `<p>log <sub>2</sub> n</p>`,
+		},
+		{
+			desc: "Pandoc: spaces must be escaped",
+			md:   `P~a cat~ and P~a\ cat~`,
+			html: `<p>P~a cat~ and P<sub>a cat</sub></p>`,
+		},
+		{
+			desc: "Pandoc: inline markdown inside a subscript",
+			md:   `x~*i*~ and x~` + "`i`" + `~`,
+			html: `<p>x<sub><em>i</em></sub> and x<sub><code>i</code></sub></p>`,
+		},
+		{
+			desc: "Pandoc: subscript inside strikeout",
+			md:   `~~H~2~O~~`,
+			html: `<p><del>H<sub>2</sub>O</del></p>`,
+		},
+	})
+}
+
+func TestDialectMarkdownItSub(t *testing.T) {
+	mdTest := goldmark.New(
+		goldmark.WithExtensions(
+			NewSubscript(DialectMarkdownItSub),
+		),
+	)
+
+	doDialectTestCases(t, mdTest, []TestCase{
+		{
+			desc: "markdown-it-sub: subscript",
+			md:   `H~2~0`,
+			html: `<p>H<sub>2</sub>0</p>`,
+		},
+		{
+			desc: "markdown-it-sub: escaped spaces",
+			md:   `H~foo\ bar~`,
+			html: `<p>H<sub>foo bar</sub></p>`,
+		},
+		{
+			desc: "markdown-it-sub: unescaped spaces",
+			md:   `H~foo bar~`,
+			html: `<p>H~foo bar~</p>`,
+		},
+		{
+			desc: "markdown-it-sub: subscript at beginning of line",
+			md:   `~foo~`,
+			html: `<p><sub>foo</sub></p>`,
+		},
+		{
+			desc: "markdown-it-sub: content is plain text",
+			md:   `x~*i*~`,
+			html: `<p>x<sub>*i*</sub></p>`,
+		},
+		{
+			desc: "markdown-it-sub: strikethrough",
+			md:   `~~foo~~ bar`,
+			html: `<p><del>foo</del> bar</p>`,
+		},
+	})
+}
+
+func TestDialectGFMSafe(t *testing.T) {
+	mdTest := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			NewSubscript(DialectGFMSafe),
+		),
+	)
+
+	doDialectTestCases(t, mdTest, []TestCase{
+		{
+			desc: "GFM safe: subscript",
+			md:   `H~2~O`,
+			html: `<p>H<sub>2</sub>O</p>`,
+		},
+		{
+			desc: "GFM safe: tildes that GitHub pairs differently",
+			md:   `~H~2~O~ and a~b c~d~e`,
+			html: `<p><del>H<sub>2</sub>O</del> and a~b c<sub>d</sub>e</p>`,
+		},
+		{
+			desc: "GFM safe: single tilde strikethrough",
+			md:   `~Hi~ Hello, world!`,
+			html: `<p><del>Hi</del> Hello, world!</p>`,
+		},
+		{
+			desc: "GFM safe: double tilde strikethrough",
+			md:   `~~Hi~~ Hello, ~there~ world!`,
+			html: `<p><del>Hi</del> Hello, <del>there</del> world!</p>`,
+		},
+		{
+			desc: "GFM safe: backslash before a space is not an escape",
+			md:   `H~a\ b~O`,
+			html: `<p>H<del>a\ b</del>O</p>`,
+		},
+		{
+			desc: "GFM safe: three tildes are literal",
+			md:   `This will ~~~not~~~ strike.`,
+			html: `<p>This will ~~~not~~~ strike.</p>`,
+		},
+	})
+}
+
+func TestDialectMultiMarkdown(t *testing.T) {
+	mdTest := goldmark.New(
+		goldmark.WithExtensions(
+			NewSubscript(DialectMultiMarkdown),
+		),
+	)
+
+	doDialectTestCases(t, mdTest, []TestCase{
+		{
+			desc: "MultiMarkdown: subscript",
+			md:   `H~2~O`,
+			html: `<p>H<sub>2</sub>O</p>`,
+		},
+		{
+			desc: "MultiMarkdown: subscript after whitespace",
+			md:   `x ~y~`,
+			html: `<p>x <sub>y</sub></p>`,
+		},
+		{
+			desc: "MultiMarkdown: subscript at beginning of line",
+			md:   `~2~O`,
+			html: `<p><sub>2</sub>O</p>`,
+		},
+		{
+			desc: "MultiMarkdown: no spaces",
+			md:   `x~a b~ and x~a\ b~`,
+			html: `<p>x~a b~ and x~a\ b~</p>`,
+		},
+		{
+			desc: "MultiMarkdown: no strikethrough",
+			md:   `~~text~~`,
+			html: `<p>~~text~~</p>`,
+		},
+	})
+}
+
+func TestDialectObsidian(t *testing.T) {
+	mdTest := goldmark.New(
+		goldmark.WithExtensions(
+			extension.Table,
+			extension.TaskList,
+			NewSubscript(DialectObsidian),
+		),
+	)
+
+	doDialectTestCases(t, mdTest, []TestCase{
+		{
+			desc: "Obsidian: strikethrough",
+			md:   `~~Striked out text~~`,
+			html: `<p><del>Striked out text</del></p>`,
+		},
+		{
+			desc: "Obsidian: single tildes are literal",
+			md:   `~Striked out text~`,
+			html: `<p>~Striked out text~</p>`,
+		},
+		{
+			desc: "Obsidian: subscript",
+			md:   `H~2~O`,
+			html: `<p>H<sub>2</sub>O</p>`,
+		},
+		{
+			desc: "Obsidian: no subscript after whitespace",
+			md:   `a ~b~ c`,
+			html: `<p>a ~b~ c</p>`,
+		},
+		{
+			desc: "Obsidian: no subscript at beginning of line",
+			md:   `~2~O`,
+			html: `<p>~2~O</p>`,
+		},
+		{
+			desc: "Obsidian: subscript inside strikethrough",
+			md:   `~~H~2~O~~`,
+			html: `<p><del>H<sub>2</sub>O</del></p>`,
+		},
+	})
+}
+
+func TestDialectOverride(t *testing.T) {
+	mdTest := goldmark.New(
+		goldmark.WithExtensions(
+			NewSubscript(DialectObsidian, WithAllowAfterWhitespace()),
+		),
+	)
+
+	doDialectTestCases(t, mdTest, []TestCase{
+		{
+			desc: "Options after a preset override it",
+			md:   `a ~b~ c`,
+			html: `<p>a <sub>b</sub> c</p>`,
+		},
+	})
+}
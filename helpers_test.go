@@ -0,0 +1,68 @@
+package subscript
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// testMarkdownRenderer writes the few node kinds used by the tests back to markdown.
+type testMarkdownRenderer struct{}
+
+func (testMarkdownRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindParagraph, func(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			_ = w.WriteByte('\n')
+		}
+		return ast.WalkContinue, nil
+	})
+	reg.Register(ast.KindText, func(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			_, _ = w.Write(n.(*ast.Text).Segment.Value(source))
+		}
+		return ast.WalkContinue, nil
+	})
+	reg.Register(ast.KindEmphasis, func(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+		_ = w.WriteByte('*')
+		return ast.WalkContinue, nil
+	})
+}
+
+// renderOutput parses md with opts and renders it with r for the subscripts and testMarkdownRenderer, which
+// writes text and emphasis as markdown, for the other nodes.
+func renderOutput(t *testing.T, r renderer.NodeRenderer, opts []SubscriptOption, md string) string {
+	t.Helper()
+	p := goldmark.New(goldmark.WithExtensions(NewSubscript(opts...))).Parser()
+	output := renderer.NewRenderer(renderer.WithNodeRenderers(
+		util.Prioritized(testMarkdownRenderer{}, 1000),
+		util.Prioritized(r, 100),
+	))
+	var buf bytes.Buffer
+	source := []byte(md)
+	if err := output.Render(&buf, source, p.Parse(text.NewReader(source))); err != nil {
+		t.Fatal(err)
+	}
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+// outputTestCase is markdown and the output a subscript renderer for another format writes for it.
+type outputTestCase struct {
+	md     string
+	output string
+}
+
+// testOutputFormat checks the output of the subscript renderer r for each test case, with the markdown
+// parsed with opts.
+func testOutputFormat(t *testing.T, r renderer.NodeRenderer, opts []SubscriptOption, testCases []outputTestCase) {
+	t.Helper()
+	for _, tc := range testCases {
+		if got := renderOutput(t, r, opts, tc.md); got != tc.output {
+			t.Errorf("%s: got %q, want %q", tc.md, got, tc.output)
+		}
+	}
+}
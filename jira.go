@@ -0,0 +1,66 @@
+package subscript
+
+import (
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// SubscriptJiraRenderer renders Subscript nodes to Jira and Confluence wiki markup, for a goldmark
+// renderer that produces wiki markup. The extension registers it with WithOutputFormat(OutputJira).
+//
+// Wiki markup subscripts are constrained: "~2~" is only a subscript between spaces or punctuation. Inside
+// words ("H~2~O") or next to nodes that are not text, the unconstrained form "H{~}2{~}O" is written instead.
+// Wiki markup characters in the content are escaped with a backslash, and a backslash is written as a
+// character reference, since "\\" is a line break. Content that is not plain text (see WithInlineMarkdown)
+// is left to the other renderers between the tildes.
+type SubscriptJiraRenderer struct{}
+
+// NewSubscriptJiraRenderer returns a new SubscriptJiraRenderer.
+func NewSubscriptJiraRenderer() renderer.NodeRenderer {
+	return &SubscriptJiraRenderer{}
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *SubscriptJiraRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindSubscript, r.renderSubscript)
+}
+
+func (r *SubscriptJiraRenderer) renderSubscript(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	delimiter := "{~}"
+	if before, after := adjacentRunes(n, source); isJiraBoundary(before) && isJiraBoundary(after) {
+		delimiter = "~"
+	}
+	if !isTextOnly(n) {
+		_, _ = w.WriteString(delimiter)
+		return ast.WalkContinue, nil
+	}
+	if entering {
+		_, _ = w.WriteString(delimiter)
+		writeJira(w, appendPlainText(nil, n, source))
+		_, _ = w.WriteString(delimiter)
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// isJiraBoundary reports whether constrained wiki markup can start after, or end before, r.
+func isJiraBoundary(r rune) bool {
+	return r != 0 && r != '~' && (unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r))
+}
+
+// writeJira writes s for use in wiki markup, escaping markup characters.
+func writeJira(w util.BufWriter, s []byte) {
+	for _, b := range s {
+		switch b {
+		case '\\':
+			_, _ = w.WriteString("&#92;")
+			continue
+		case '*', '_', '?', '-', '+', '^', '~', '{', '}', '[', ']', '|', '!', '#':
+			_ = w.WriteByte('\\')
+		}
+		_ = w.WriteByte(b)
+	}
+}
@@ -0,0 +1,16 @@
+package subscript
+
+import (
+	"testing"
+)
+
+func TestSubscriptJiraRenderer(t *testing.T) {
+	testOutputFormat(t, NewSubscriptJiraRenderer(), []SubscriptOption{WithAllowAfterWhitespace()}, []outputTestCase{
+		{`H~2~O`, `H{~}2{~}O`},
+		{`see ~note~ here`, `see ~note~ here`},
+		{`(a ~2~)`, `(a ~2~)`},
+		{`x~n-1~.`, `x{~}n\-1{~}.`},
+		{`x~a*b\\c~`, `x{~}a\*b&#92;c{~}`},
+		{`*a* ~2~~3~`, `*a* {~}2{~}{~}3{~}`},
+	})
+}
@@ -0,0 +1,220 @@
+package subscript
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// LaTeXMathMode selects when SubscriptLaTeXRenderer writes subscripts in math mode.
+type LaTeXMathMode int
+
+const (
+	// LaTeXMathNever writes all subscripts in text mode: "\textsubscript{max}". This is the default.
+	LaTeXMathNever LaTeXMathMode = iota
+
+	// LaTeXMathIndex writes subscripts that look like a mathematical index in math mode ("$_{i+1}$"), and
+	// other subscripts in text mode. An index is a single letter, a number, or single letters and numbers
+	// joined by "+", "-", "=" or ",": "i", "12", "n-1", "i,j".
+	LaTeXMathIndex
+
+	// LaTeXMathAlways writes all subscripts in math mode.
+	LaTeXMathAlways
+)
+
+// SubscriptLaTeXRenderer renders Subscript nodes to LaTeX, for a goldmark renderer that produces LaTeX. The
+// extension registers it with WithOutputFormat(OutputLaTeX).
+//
+// Subscripts are written in text mode as "\textsubscript{...}", with the LaTeX special characters of the
+// content escaped; WithLaTeXMath selects math mode ("$_{...}$") for some or all subscripts. Content that is
+// not plain text (see WithInlineMarkdown) is left to the other renderers inside "\textsubscript{...}".
+type SubscriptLaTeXRenderer struct {
+	math LaTeXMathMode
+}
+
+// NewSubscriptLaTeXRenderer returns a new SubscriptLaTeXRenderer with the given options.
+func NewSubscriptLaTeXRenderer(opts ...SubscriptOption) renderer.NodeRenderer {
+	return newSubscriptLaTeXRenderer(newConfig(opts))
+}
+
+func newSubscriptLaTeXRenderer(config Config) *SubscriptLaTeXRenderer {
+	return &SubscriptLaTeXRenderer{
+		math: config.LaTeXMath,
+	}
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *SubscriptLaTeXRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindSubscript, r.renderSubscript)
+}
+
+func (r *SubscriptLaTeXRenderer) renderSubscript(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	return renderLaTeXScript(w, source, n, entering, r.math, `\textsubscript{`, "$_{")
+}
+
+// SuperscriptLaTeXRenderer renders Superscript nodes to LaTeX, for a goldmark renderer that produces LaTeX. The
+// extension registers it with WithOutputFormat(OutputLaTeX).
+//
+// Superscripts are written like subscripts, as "\textsuperscript{...}" in text mode and "$^{...}$" in math mode.
+type SuperscriptLaTeXRenderer struct {
+	math LaTeXMathMode
+}
+
+// NewSuperscriptLaTeXRenderer returns a new SuperscriptLaTeXRenderer with the given options.
+func NewSuperscriptLaTeXRenderer(opts ...SubscriptOption) renderer.NodeRenderer {
+	return newSuperscriptLaTeXRenderer(newConfig(opts))
+}
+
+func newSuperscriptLaTeXRenderer(config Config) *SuperscriptLaTeXRenderer {
+	return &SuperscriptLaTeXRenderer{
+		math: config.LaTeXMath,
+	}
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *SuperscriptLaTeXRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindSuperscript, r.renderSuperscript)
+}
+
+func (r *SuperscriptLaTeXRenderer) renderSuperscript(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	return renderLaTeXScript(w, source, n, entering, r.math, `\textsuperscript{`, "$^{")
+}
+
+// renderLaTeXScript renders the subscript or superscript n with the text mode command text ("\textsubscript{")
+// or, as selected by mode, in math mode after math ("$_{").
+func renderLaTeXScript(w util.BufWriter, source []byte, n ast.Node, entering bool,
+	mode LaTeXMathMode, text, math string) (ast.WalkStatus, error) {
+	if !isTextOnly(n) {
+		if entering {
+			_, _ = w.WriteString(text)
+		} else {
+			_ = w.WriteByte('}')
+		}
+		return ast.WalkContinue, nil
+	}
+	if !entering {
+		return ast.WalkSkipChildren, nil
+	}
+	content := appendPlainText(nil, n, source)
+	if mode == LaTeXMathAlways || mode == LaTeXMathIndex && isMathIndex(content) {
+		_, _ = w.WriteString(math)
+		writeLaTeXMath(w, content)
+		_, _ = w.WriteString("}$")
+	} else {
+		_, _ = w.WriteString(text)
+		writeLaTeXText(w, content)
+		_ = w.WriteByte('}')
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// isTextOnly reports whether the children of n are all text.
+func isTextOnly(n ast.Node) bool {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c.(type) {
+		case *ast.Text, *ast.String:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isMathIndex reports whether s is a single letter, a number, or single letters and numbers joined by
+// "+", "-", "=" or ",", optionally after a sign ("-1"). Every operator must be between two terms, so
+// "i++1" is not an index.
+func isMathIndex(s []byte) bool {
+	if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		s = s[1:]
+	}
+	for len(s) > 0 {
+		end := bytes.IndexAny(s, "+-=,")
+		if end < 0 {
+			end = len(s)
+		}
+		term := s[:end]
+		if !isNumber(term) && !(len(term) == 1 && util.IsAlphaNumeric(term[0])) {
+			return false
+		}
+		if end == len(s) {
+			return true
+		}
+		s = s[end+1:]
+	}
+	return false
+}
+
+// SubSuperscriptLaTeXRenderer renders SubSuperscript nodes to LaTeX, for a goldmark renderer that produces LaTeX.
+// NewScripts registers it with WithOutputFormat(OutputLaTeX) and WithStackedScripts.
+//
+// A pair is written in math mode with an empty base, so "x~i~^2^" becomes "x$_{i}^{2}$". A base taken out of the
+// text (see SubSuperscriptNode.Base) is written in math mode too: "$x_{i}^{2}$".
+type SubSuperscriptLaTeXRenderer struct{}
+
+// NewSubSuperscriptLaTeXRenderer returns a new SubSuperscriptLaTeXRenderer.
+func NewSubSuperscriptLaTeXRenderer() renderer.NodeRenderer {
+	return &SubSuperscriptLaTeXRenderer{}
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *SubSuperscriptLaTeXRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindSubSuperscript, r.renderSubSuperscript)
+}
+
+func (r *SubSuperscriptLaTeXRenderer) renderSubSuperscript(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		stacked := n.(*SubSuperscriptNode)
+		_ = w.WriteByte('$')
+		writeLaTeXMath(w, stacked.Base.Value(source))
+		_, _ = w.WriteString("_{")
+		writeLaTeXMath(w, appendPlainText(nil, stacked.Subscript(), source))
+		_, _ = w.WriteString("}^{")
+		writeLaTeXMath(w, appendPlainText(nil, stacked.Superscript(), source))
+		_, _ = w.WriteString("}$")
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// writeLaTeXMath writes s for use in LaTeX math mode, escaping LaTeX special characters.
+func writeLaTeXMath(w util.BufWriter, s []byte) {
+	for _, b := range s {
+		switch b {
+		case '_', '%', '&', '#', '$', '{', '}':
+			_ = w.WriteByte('\\')
+			_ = w.WriteByte(b)
+		case '\\':
+			_, _ = w.WriteString(`\backslash{}`)
+		case '^':
+			// \hat is an accent, so it needs something to sit on
+			_, _ = w.WriteString(`\hat{\ }`)
+		case '~':
+			_, _ = w.WriteString(`\sim{}`)
+		default:
+			_ = w.WriteByte(b)
+		}
+	}
+}
+
+// writeLaTeXText writes s for use in LaTeX text mode, escaping LaTeX special characters.
+func writeLaTeXText(w util.BufWriter, s []byte) {
+	for _, b := range s {
+		switch b {
+		case '_', '%', '&', '#', '$', '{', '}':
+			_ = w.WriteByte('\\')
+			_ = w.WriteByte(b)
+		case '\\':
+			_, _ = w.WriteString(`\textbackslash{}`)
+		case '^':
+			_, _ = w.WriteString(`\textasciicircum{}`)
+		case '~':
+			_, _ = w.WriteString(`\textasciitilde{}`)
+		default:
+			_ = w.WriteByte(b)
+		}
+	}
+}
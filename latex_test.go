@@ -0,0 +1,32 @@
+package subscript
+
+import (
+	"testing"
+)
+
+func TestSubscriptLaTeXRenderer(t *testing.T) {
+	testCases := []struct {
+		mode  LaTeXMathMode
+		md    string
+		latex string
+	}{
+		{LaTeXMathNever, `H~2~O`, `H\textsubscript{2}O`},
+		{LaTeXMathNever, `x~a_b%~`, `x\textsubscript{a\_b\%}`},
+		{LaTeXMathNever, `x~&#{$}~`, `x\textsubscript{\&\#\{\$\}}`},
+		{LaTeXMathNever, `x~\\^~`, `x\textsubscript{\textbackslash{}\textasciicircum{}}`},
+		{LaTeXMathNever, `x~*i*~`, `x\textsubscript{*i*}`},
+		{LaTeXMathIndex, `x~i+1~ and x~2~ and x~i,j~`, `x$_{i+1}$ and x$_{2}$ and x$_{i,j}$`},
+		{LaTeXMathIndex, `T~max~ and x~i+~`, `T\textsubscript{max} and x\textsubscript{i+}`},
+		{LaTeXMathIndex, `x~-1~ and x~i++1~ and x~i--1~ and x~,i~`,
+			`x$_{-1}$ and x\textsubscript{i++1} and x\textsubscript{i--1} and x\textsubscript{,i}`},
+		{LaTeXMathAlways, `T~max~ and x~a_b~`, `T$_{max}$ and x$_{a\_b}$`},
+		{LaTeXMathAlways, `x~a^b~`, `x$_{a\hat{\ }b}$`},
+	}
+
+	for _, tc := range testCases {
+		r := NewSubscriptLaTeXRenderer(WithLaTeXMath(tc.mode))
+		if got := renderOutput(t, r, []SubscriptOption{WithInlineMarkdown()}, tc.md); got != tc.latex {
+			t.Errorf("%s: got %q, want %q", tc.md, got, tc.latex)
+		}
+	}
+}
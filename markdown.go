@@ -0,0 +1,149 @@
+package subscript
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// SubscriptMarkdownRenderer renders Subscript nodes back to markdown, for formatters that write an AST
+// out as markdown. It is meant to be registered with such a renderer, next to its own node renderers.
+//
+// A subscript is written as "~content~" when that re-parses to the same subscript under the options the
+// renderer was created with: content parsed from tildes is written as it was in the source, other content
+// is escaped ("T~max\ value~"). When tildes would not re-parse as a subscript, for example after whitespace
+// where they would become a strikethrough, for content with a tilde, or right after another subscript with
+// WithWhitespaceFlanking or a TildeMode that owns strikethroughs ("~a~~b~" is a strikethrough there), the
+// subscript is written as raw HTML ("<sub>a~b</sub>").
+//
+// Raw HTML is only parsed back into a subscript when the markdown is read with WithHTMLInput. Without it,
+// the output renders to the same HTML, but re-parses to raw HTML nodes instead of a Subscript node.
+type SubscriptMarkdownRenderer struct {
+	parser *subscriptParser
+}
+
+// NewSubscriptMarkdownRenderer returns a new SubscriptMarkdownRenderer for markdown that is parsed with
+// the given options.
+func NewSubscriptMarkdownRenderer(opts ...SubscriptOption) renderer.NodeRenderer {
+	return newSubscriptMarkdownRenderer(newConfig(opts))
+}
+
+func newSubscriptMarkdownRenderer(config Config) *SubscriptMarkdownRenderer {
+	return &SubscriptMarkdownRenderer{
+		parser: newSubscriptParser(config),
+	}
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *SubscriptMarkdownRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindSubscript, r.renderSubscript)
+}
+
+func (r *SubscriptMarkdownRenderer) renderSubscript(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	// Content made of text is written here; other content (inline markdown) is left to the other renderers
+	if content, ok := r.textContent(n, source); ok {
+		if entering {
+			if r.opensAfter(n, source) && r.isValidContent(content) {
+				_ = w.WriteByte('~')
+				_, _ = w.Write(content)
+				_ = w.WriteByte('~')
+			} else {
+				_, _ = w.WriteString("<sub>")
+				_, _ = w.Write(util.EscapeHTML(appendPlainText(nil, n, source)))
+				_, _ = w.WriteString("</sub>")
+			}
+		}
+		return ast.WalkSkipChildren, nil
+	}
+
+	tilde := r.parser.InlineMarkdown && r.opensAfter(n, source) &&
+		r.isValidContent(r.escapeContent(appendPlainText(nil, n, source)))
+	switch {
+	case tilde:
+		_ = w.WriteByte('~')
+	case entering:
+		_, _ = w.WriteString("<sub>")
+	default:
+		_, _ = w.WriteString("</sub>")
+	}
+	return ast.WalkContinue, nil
+}
+
+// textContent returns the content of n as markdown, if n only holds text: the source of text parsed from
+// tildes, or the escaped value of other text.
+func (r *SubscriptMarkdownRenderer) textContent(n ast.Node, source []byte) ([]byte, bool) {
+	fromSource := true
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch t := c.(type) {
+		case *ast.Text:
+			fromSource = fromSource && !t.SoftLineBreak() && !t.HardLineBreak()
+		case *ast.String:
+			fromSource = false
+		default:
+			return nil, false
+		}
+	}
+	first, firstOk := n.FirstChild().(*ast.Text)
+	last, lastOk := n.LastChild().(*ast.Text)
+	if fromSource && firstOk && lastOk && first.Segment.Start <= last.Segment.Stop {
+		// escaped spaces split the content into several text nodes; the source still has the backslashes
+		return source[first.Segment.Start:last.Segment.Stop], true
+	}
+	return r.escapeContent(appendPlainText(nil, n, source)), true
+}
+
+// escapeContent escapes text so it is parsed back as the same text inside tildes.
+func (r *SubscriptMarkdownRenderer) escapeContent(text []byte) []byte {
+	var buf []byte
+	for _, c := range text {
+		switch {
+		case c == '\\' || c == '&':
+			buf = append(buf, '\\', c)
+		case c == ' ' && r.parser.EscapedSpaces:
+			buf = append(buf, '\\', ' ')
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
+
+// isValidContent reports whether content, written between tildes, is parsed as a subscript.
+func (r *SubscriptMarkdownRenderer) isValidContent(content []byte) bool {
+	if bytes.IndexByte(content, '~') >= 0 || !r.parser.isValidContent(content) {
+		return false
+	}
+	// An escaped space at either end keeps the tilde next to it from flanking the content
+	return !r.parser.WhitespaceFlanking || content[0] != '\\' && !bytes.HasSuffix(content, []byte("\\ "))
+}
+
+// opensAfter reports whether a tilde written before n can open a subscript.
+func (r *SubscriptMarkdownRenderer) opensAfter(n ast.Node, source []byte) bool {
+	var before []byte
+	switch prev := n.PreviousSibling().(type) {
+	case nil:
+		if n.Parent() != nil && n.Parent().Type() == ast.TypeInline {
+			return true // after the delimiter of the parent ("*~2~*")
+		}
+	case *ast.Text:
+		if !prev.SoftLineBreak() && !prev.HardLineBreak() {
+			before = prev.Segment.Value(source)
+		}
+	case *ast.String:
+		before = prev.Value
+	case *Node:
+		// "~a~~b~" is a strikethrough when double tildes can close a strikethrough or tildes flank like emphasis
+		return !r.parser.WhitespaceFlanking && !r.parser.TildeMode.ownsStrikethrough()
+	default:
+		return true
+	}
+	if len(before) == 0 {
+		return r.parser.canOpenAfter('\n')
+	}
+	last, _ := utf8.DecodeLastRune(before)
+	return last != '~' && r.parser.canOpenAfter(last)
+}
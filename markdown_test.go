@@ -0,0 +1,152 @@
+package subscript
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// astShape writes the kinds of the nodes below n and their text, with escapes and character references
+// resolved, so trees parsed from different markdown can be compared.
+func astShape(n ast.Node, source []byte) string {
+	var b strings.Builder
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		switch c := c.(type) {
+		case *ast.Text:
+			if entering {
+				b.Write(util.UnescapePunctuations(util.ResolveNumericReferences(util.ResolveEntityNames(c.Segment.Value(source)))))
+			}
+		case *ast.String:
+			if entering {
+				b.Write(c.Value)
+			}
+		default:
+			if entering {
+				b.WriteString("(" + c.Kind().String() + " ")
+			} else {
+				b.WriteString(")")
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
+
+func TestSubscriptMarkdownRenderer(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		opts     []SubscriptOption
+		md       string
+		markdown string
+	}{
+		{
+			desc:     "Markdown: subscripts are written as in the source",
+			md:       `H~2~O and T~max\ value~ and x~a\*b~`,
+			markdown: `H~2~O and T~max\ value~ and x~a\*b~`,
+		},
+		{
+			desc:     "Markdown: subscripts after whitespace",
+			opts:     []SubscriptOption{WithAllowAfterWhitespace()},
+			md:       `H ~2~O`,
+			markdown: `H ~2~O`,
+		},
+		{
+			desc:     "Markdown: subscripts from HTML that would not re-parse",
+			opts:     []SubscriptOption{WithHTMLInput()},
+			md:       `H <sub>2</sub>O and x<sub>a~b</sub> and x<sub>2</sub>`,
+			markdown: `H <sub>2</sub>O and x<sub>a~b</sub> and x~2~`,
+		},
+		{
+			desc:     "Markdown: character references in HTML fallback",
+			opts:     []SubscriptOption{WithHTMLInput()},
+			md:       `H <sub>a&amp;b</sub>`,
+			markdown: `H <sub>a&amp;b</sub>`,
+		},
+		{
+			desc:     "Markdown: adjacent subscripts",
+			opts:     []SubscriptOption{WithHTMLInput()},
+			md:       `x<sub>a</sub><sub>b</sub>`,
+			markdown: `x~a~~b~`,
+		},
+		{
+			desc:     "Markdown: adjacent subscripts with whitespace flanking",
+			opts:     []SubscriptOption{WithHTMLInput(), WithWhitespaceFlanking()},
+			md:       `x<sub>a</sub><sub>b</sub>`,
+			markdown: `x~a~<sub>b</sub>`,
+		},
+		{
+			desc:     "Markdown: adjacent subscripts with strikethroughs",
+			opts:     []SubscriptOption{WithHTMLInput(), WithTildeMode(TildeModeGFM)},
+			md:       `x<sub>a</sub><sub>b</sub>`,
+			markdown: `x~a~<sub>b</sub>`,
+		},
+		{
+			desc:     "Markdown: text from Unicode subscripts",
+			opts:     []SubscriptOption{WithUnicodeInput()},
+			md:       `xᵢ₊₁`,
+			markdown: `x~i+1~`,
+		},
+		{
+			desc:     "Markdown: inline markdown",
+			opts:     []SubscriptOption{WithInlineMarkdown()},
+			md:       `x~*i*~`,
+			markdown: `x~*i*~`,
+		},
+		{
+			desc:     "Markdown: inline markdown without the option",
+			opts:     []SubscriptOption{WithHTMLInput()},
+			md:       `x<sub>*i*</sub>`,
+			markdown: `x<sub>*i*</sub>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			md := goldmark.New(
+				goldmark.WithExtensions(
+					extension.Strikethrough,
+					NewSubscript(tc.opts...),
+				),
+				goldmark.WithRendererOptions(html.WithUnsafe()),
+			)
+			markdown := renderer.NewRenderer(renderer.WithNodeRenderers(
+				util.Prioritized(testMarkdownRenderer{}, 1000),
+				util.Prioritized(NewSubscriptMarkdownRenderer(tc.opts...), 100),
+			))
+
+			source := []byte(tc.md)
+			var buf bytes.Buffer
+			if err := markdown.Render(&buf, source, md.Parser().Parse(text.NewReader(source))); err != nil {
+				t.Fatal(err)
+			}
+			got := string(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+			if got != tc.markdown {
+				t.Errorf("got %q, want %q", got, tc.markdown)
+			}
+
+			// The markdown must parse to the same tree and render the same as the original source
+			if want, got := astShape(md.Parser().Parse(text.NewReader(source)), source),
+				astShape(md.Parser().Parse(text.NewReader([]byte(got))), []byte(got)); want != got {
+				t.Errorf("round trip parses to %s, want %s", got, want)
+			}
+			var want, roundTrip bytes.Buffer
+			if err := md.Convert(source, &want); err != nil {
+				t.Fatal(err)
+			}
+			if err := md.Convert([]byte(got), &roundTrip); err != nil {
+				t.Fatal(err)
+			}
+			if want.String() != roundTrip.String() {
+				t.Errorf("round trip renders %q, want %q", roundTrip.String(), want.String())
+			}
+		})
+	}
+}
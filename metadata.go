@@ -0,0 +1,331 @@
+package subscript
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// formulaMetadataTransformer attaches the molar mass and Hill notation of chemical formulas as attributes.
+type formulaMetadataTransformer struct{}
+
+// NewFormulaMetadataTransformer returns an ASTTransformer that computes the molar mass and Hill notation
+// of valid chemical formulas and attaches them as attributes:
+//
+//	<span class="chem" title="C6H12O6, 180.16 g/mol" data-formula="C6H12O6" data-molar-mass="180.16">
+//
+// Runs of text and subscripts that form a valid formula ("C~6~H~12~O~6~") are wrapped in a Formula node;
+// a coefficient before the run ("2H~2~O") is left outside, and a charge written as a superscript right after
+// it ("SO~4~^2-^") is included. Formula nodes created from "ce:" code spans get the same attributes, without
+// their coefficient. Runs that are not valid formulas are left alone.
+//
+// The charge of an ion is kept in the formula: "SO~4~^2-^" has the formula "O4S^2-". The molar mass does not
+// account for the mass of the electrons.
+func NewFormulaMetadataTransformer() parser.ASTTransformer {
+	return formulaMetadataTransformer{}
+}
+
+// Transform implements parser.ASTTransformer.
+func (formulaMetadataTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	var formulas []*FormulaNode
+	var runs [][]formulaPart
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if formula, ok := n.(*FormulaNode); ok {
+			formulas = append(formulas, formula)
+			return ast.WalkSkipChildren, nil
+		}
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			if formulaSubscript(c) == nil {
+				continue
+			}
+			var parts []formulaPart
+			parts, c = formulaRun(c, source)
+			if looksLikeFormula(parts) {
+				runs = append(runs, parts)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, formula := range formulas {
+		setFormulaMetadata(formula, formula.Formula[coefficientLen(formula.Formula):])
+	}
+	for _, parts := range runs {
+		// the coefficient is not part of the formula
+		coefficient := coefficientLen(parts[0].value)
+		parts[0].value = parts[0].value[coefficient:]
+		parts[0].start += coefficient
+
+		// counts must be subscripts: "H~12~0~6~" is not "H1206"
+		if len(validateFormula(nil, parts, source)) > 0 {
+			continue
+		}
+		var formula []byte
+		for _, part := range parts {
+			formula = append(formula, part.value...)
+		}
+		if charge, ok := formulaCharge(parts[len(parts)-1], source); ok {
+			formula = append(append(formula, '^'), charge.value...)
+			parts = append(parts, charge)
+		}
+		if _, ok := formulaComposition(formula); !ok {
+			continue
+		}
+		setFormulaMetadata(wrapFormulaRun(parts), formula)
+	}
+}
+
+// coefficientLen returns the length of the coefficient at the start of formula ("2" in "2H2O").
+func coefficientLen(formula []byte) int {
+	return len(formula) - len(bytes.TrimLeft(formula, "0123456789"))
+}
+
+// formulaCharge returns the charge of a formula run that ends with last: a superscript charge ("^2-^") directly
+// after the run, or the superscript of a stacked pair that ends it ("~4~^2-^" grouped by WithStackedScripts).
+func formulaCharge(last formulaPart, source []byte) (formulaPart, bool) {
+	var sup *SuperscriptNode
+	next := last.node.NextSibling()
+	switch {
+	case last.subscript:
+		if stacked, ok := last.node.(*SubSuperscriptNode); ok {
+			sup, next = stacked.Superscript(), nil
+		}
+	case last.start+len(last.value) != last.node.(*ast.Text).Segment.Stop ||
+		last.node.(*ast.Text).SoftLineBreak() || last.node.(*ast.Text).HardLineBreak():
+		next = nil
+	}
+	if s, ok := next.(*SuperscriptNode); ok {
+		sup = s
+	}
+	if sup == nil {
+		return formulaPart{}, false
+	}
+	value := appendNodeText(nil, sup, source)
+	if len(value) == 0 || chargeEnd(value, 0) != len(value) {
+		return formulaPart{}, false
+	}
+	if next == nil {
+		// the charge is already inside the last part
+		return formulaPart{value: value, start: -1, node: last.node, subscript: true}, true
+	}
+	return formulaPart{value: value, start: -1, node: sup, subscript: true}, true
+}
+
+// wrapFormulaRun moves the nodes of a formula run into a new Formula node and returns it. Text nodes that
+// are only partly in the run are split.
+func wrapFormulaRun(parts []formulaPart) *FormulaNode {
+	first, last := &parts[0], &parts[len(parts)-1]
+	if !first.subscript {
+		first.node = isolateText(first.node.(*ast.Text), text.NewSegment(first.start, first.start+len(first.value)))
+	}
+	if !last.subscript {
+		last.node = isolateText(last.node.(*ast.Text), text.NewSegment(last.start, last.start+len(last.value)))
+	}
+
+	wrapper := &FormulaNode{}
+	parent := first.node.Parent()
+	parent.InsertBefore(parent, first.node, wrapper)
+	for c := first.node; c != nil; {
+		next := c.NextSibling()
+		wrapper.AppendChild(wrapper, c)
+		if c == last.node {
+			break
+		}
+		c = next
+	}
+	return wrapper
+}
+
+// isolateText splits t so segment, which must be inside t, is a Text node of its own, and returns that node.
+func isolateText(t *ast.Text, segment text.Segment) *ast.Text {
+	if t.Segment == segment && !t.SoftLineBreak() && !t.HardLineBreak() {
+		return t
+	}
+	parent := t.Parent()
+	if segment.Start > t.Segment.Start {
+		parent.InsertBefore(parent, t, ast.NewTextSegment(t.Segment.WithStop(segment.Start)))
+	}
+	isolated := ast.NewTextSegment(segment)
+	parent.InsertBefore(parent, t, isolated)
+	t.Segment = t.Segment.WithStart(segment.Stop)
+	if t.Segment.IsEmpty() && !t.SoftLineBreak() && !t.HardLineBreak() {
+		parent.RemoveChild(parent, t)
+	}
+	return isolated
+}
+
+// setFormulaMetadata sets the metadata attributes of n for formula, if it is a valid formula.
+func setFormulaMetadata(n *FormulaNode, formula []byte) {
+	composition, ok := formulaComposition(formula)
+	if !ok {
+		return
+	}
+	if n.Formula == nil {
+		n.Formula = formula
+	}
+	hill := hillNotation(composition)
+	if charge := formulaChargeSuffix(formula); len(charge) > 0 {
+		hill += "^" + string(charge)
+	}
+	mass := strconv.FormatFloat(molarMass(composition), 'f', 2, 64)
+	n.SetAttributeString("title", hill+", "+mass+" g/mol")
+	n.SetAttributeString("data-formula", hill)
+	n.SetAttributeString("data-molar-mass", mass)
+}
+
+// formulaChargeSuffix returns the charge at the end of formula, without the caret: "2-" for "SO4^2-" and "+"
+// for "Na+".
+func formulaChargeSuffix(formula []byte) []byte {
+	if i := bytes.IndexByte(formula, '^'); i >= 0 {
+		return formula[i+1:]
+	}
+	if n := len(formula); n > 1 && (formula[n-1] == '+' || formula[n-1] == '-') {
+		return formula[n-1:]
+	}
+	return nil
+}
+
+// formulaComposition returns the number of atoms of each element in formula, or false if formula is not a
+// valid formula.
+//
+// Formulas are written as in "ce:" code spans: element symbols with counts, brackets with counts
+// ("Ca3(PO4)2"), a charge ("SO4^2-", "Na+") and hydrate parts with coefficients ("CuSO4.5H2O").
+func formulaComposition(formula []byte) (map[string]int, bool) {
+	if i := bytes.IndexByte(formula, '^'); i >= 0 {
+		if chargeEnd(formula, i+1) != len(formula) {
+			return nil, false
+		}
+		formula = formula[:i]
+	} else if n := len(formula); n > 1 && (formula[n-1] == '+' || formula[n-1] == '-') {
+		formula = formula[:n-1]
+	}
+	formula = bytes.ReplaceAll(formula, []byte("·"), []byte{'.'})
+
+	composition := map[string]int{}
+	for _, part := range bytes.FieldsFunc(formula, func(r rune) bool { return r == '.' || r == '*' }) {
+		coefficient := 1
+		i := 0
+		for i < len(part) && isDigit(part[i]) {
+			i++
+		}
+		if i > 0 {
+			coefficient, _ = strconv.Atoi(string(part[:i]))
+		}
+		counts, ok := groupComposition(part[i:])
+		if !ok || coefficient == 0 {
+			return nil, false
+		}
+		for symbol, count := range counts {
+			composition[symbol] += coefficient * count
+		}
+	}
+	return composition, len(composition) > 0
+}
+
+// groupComposition returns the number of atoms of each element in a formula without charges or hydrate
+// parts ("Ca3(PO4)2"), or false if it is not valid.
+func groupComposition(formula []byte) (map[string]int, bool) {
+	stack := []map[string]int{{}}
+	var closers []byte
+	for i := 0; i < len(formula); {
+		c := formula[i]
+		var counts map[string]int
+		switch {
+		case c >= 'A' && c <= 'Z':
+			j := i + 1
+			if j < len(formula) && formula[j] >= 'a' && formula[j] <= 'z' {
+				j++
+			}
+			if lookupElement(formula[i:j]) == nil {
+				return nil, false
+			}
+			counts = map[string]int{string(formula[i:j]): 1}
+			i = j
+		case c == '(' || c == '[':
+			stack = append(stack, map[string]int{})
+			closer := byte(')')
+			if c == '[' {
+				closer = ']'
+			}
+			closers = append(closers, closer)
+			i++
+			continue
+		case c == ')' || c == ']':
+			if len(closers) == 0 || closers[len(closers)-1] != c {
+				return nil, false
+			}
+			counts = stack[len(stack)-1]
+			stack, closers = stack[:len(stack)-1], closers[:len(closers)-1]
+			i++
+		default:
+			return nil, false
+		}
+
+		multiplier := 1
+		j := i
+		for j < len(formula) && isDigit(formula[j]) {
+			j++
+		}
+		if j > i {
+			multiplier, _ = strconv.Atoi(string(formula[i:j]))
+			i = j
+		}
+		if multiplier == 0 {
+			return nil, false
+		}
+		for symbol, count := range counts {
+			stack[len(stack)-1][symbol] += multiplier * count
+		}
+	}
+	if len(closers) > 0 {
+		return nil, false
+	}
+	return stack[0], true
+}
+
+// hillNotation writes the composition in Hill order: carbon first, then hydrogen, then the other elements
+// in alphabetical order. Without carbon, all elements are in alphabetical order.
+func hillNotation(composition map[string]int) string {
+	symbols := make([]string, 0, len(composition))
+	for symbol := range composition {
+		symbols = append(symbols, symbol)
+	}
+	_, carbon := composition["C"]
+	sort.Slice(symbols, func(i, j int) bool {
+		if carbon {
+			for _, first := range []string{"C", "H"} {
+				if symbols[i] == first || symbols[j] == first {
+					return symbols[i] == first
+				}
+			}
+		}
+		return symbols[i] < symbols[j]
+	})
+
+	var b []byte
+	for _, symbol := range symbols {
+		b = append(b, symbol...)
+		if count := composition[symbol]; count != 1 {
+			b = strconv.AppendInt(b, int64(count), 10)
+		}
+	}
+	return string(b)
+}
+
+// molarMass returns the molar mass of the composition in g/mol.
+func molarMass(composition map[string]int) float64 {
+	// summed in table order, so the rounding does not depend on map order
+	var mass float64
+	for _, e := range elements {
+		mass += e.mass * float64(composition[e.symbol])
+	}
+	return mass
+}
@@ -0,0 +1,93 @@
+package subscript
+
+import (
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/testutil"
+)
+
+func TestFormulaMetadata(t *testing.T) {
+	mdTest := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			NewScripts(WithFormulaMetadata(), WithChemistry()),
+		),
+	)
+
+	testCases := []TestCase{
+		{
+			desc: "Metadata: formula run is wrapped",
+			md:   "Glucose is C~6~H~12~O~6~.",
+			html: `<p>Glucose is <span class="chem" title="C6H12O6, 180.16 g/mol" data-formula="C6H12O6" ` +
+				`data-molar-mass="180.16">C<sub>6</sub>H<sub>12</sub>O<sub>6</sub></span>.</p>`,
+		},
+		{
+			desc: "Metadata: Hill order without carbon",
+			md:   "H~2~SO~4~",
+			html: `<p><span class="chem" title="H2O4S, 98.07 g/mol" data-formula="H2O4S" ` +
+				`data-molar-mass="98.07">H<sub>2</sub>SO<sub>4</sub></span></p>`,
+		},
+		{
+			desc: "Metadata: brackets",
+			md:   "Ca~3~(PO~4~)~2~",
+			html: `<p><span class="chem" title="Ca3O8P2, 310.17 g/mol" data-formula="Ca3O8P2" ` +
+				`data-molar-mass="310.17">Ca<sub>3</sub>(PO<sub>4</sub>)<sub>2</sub></span></p>`,
+		},
+		{
+			desc: "Metadata: coefficient and neighbouring runs",
+			md:   "2H~2~O and CO~2~",
+			html: `<p>2<span class="chem" title="H2O, 18.02 g/mol" data-formula="H2O" data-molar-mass="18.02">` +
+				`H<sub>2</sub>O</span> and <span class="chem" title="CO2, 44.01 g/mol" data-formula="CO2" ` +
+				`data-molar-mass="44.01">CO<sub>2</sub></span></p>`,
+		},
+		{
+			desc: "Metadata: invalid formulas are left alone",
+			md:   "C~6~H~12~0~6~, Xy~2~ and T~max~",
+			html: `<p>C<sub>6</sub>H<sub>12</sub>0<sub>6</sub>, Xy<sub>2</sub> and T<sub>max</sub></p>`,
+		},
+		{
+			desc: "Metadata: ce: formulas",
+			md:   "`ce:CuSO4.5H2O` and `ce:2H2 + O2`",
+			html: `<p><span class="chem" title="CuH10O9S, 249.68 g/mol" data-formula="CuH10O9S" ` +
+				`data-molar-mass="249.68">CuSO<sub>4</sub>·5H<sub>2</sub>O</span> and ` +
+				`<span class="chem">2H<sub>2</sub> + O<sub>2</sub></span></p>`,
+		},
+		{
+			desc: "Metadata: ions",
+			md:   "`ce:[Fe(CN)6]^3-`",
+			html: `<p><span class="chem" title="C6FeN6^3-, 211.95 g/mol" data-formula="C6FeN6^3-" ` +
+				`data-molar-mass="211.95">[Fe(CN)<sub>6</sub>]<sup>3-</sup></span></p>`,
+		},
+		{
+			desc: "Metadata: ce: coefficient is not part of the formula",
+			md:   "`ce:2H2O`",
+			html: `<p><span class="chem" title="H2O, 18.02 g/mol" data-formula="H2O" data-molar-mass="18.02">` +
+				`2H<sub>2</sub>O</span></p>`,
+		},
+		{
+			desc: "Metadata: superscript charge after a run",
+			md:   "SO~4~^2-^ and Na^+^",
+			html: `<p><span class="chem" title="O4S^2-, 96.06 g/mol" data-formula="O4S^2-" data-molar-mass="96.06">` +
+				`SO<sub>4</sub><sup>2-</sup></span> and Na<sup>+</sup></p>`,
+		},
+		{
+			desc: "Metadata: charge after text that continues",
+			md:   "CO~2~ gas^2-^ and CO~2~ ^+^",
+			html: `<p><span class="chem" title="CO2, 44.01 g/mol" data-formula="CO2" data-molar-mass="44.01">` +
+				`CO<sub>2</sub></span> gas<sup>2-</sup> and <span class="chem" title="CO2, 44.01 g/mol" ` +
+				`data-formula="CO2" data-molar-mass="44.01">CO<sub>2</sub></span> <sup>+</sup></p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(mdTest, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+}
@@ -0,0 +1,459 @@
+package subscript
+
+import (
+	"strconv"
+	"unicode"
+
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+// Config holds the settings shared by the subscript parser, renderer and extension.
+type Config struct {
+	// ParserPriority is the priority of the subscript inline parser. Lower values run first.
+	ParserPriority int
+
+	// RendererPriority is the priority of the subscript renderer (see OutputFormat). Lower values run first.
+	RendererPriority int
+
+	// HTMLOptions are applied to the html.Config of the subscript HTML renderer.
+	HTMLOptions []html.Option
+
+	// AttributeFilter defines attribute names which <sub> (and <sup>) elements can have.
+	// Renderers copy the filter when they are created.
+	AttributeFilter util.BytesFilter
+
+	// AllowLineStart allows a subscript to open at the beginning of a line.
+	AllowLineStart bool
+
+	// AllowAfterWhitespace allows a subscript to open right after a whitespace character.
+	AllowAfterWhitespace bool
+
+	// EscapedSpaces allows backslash-escaped spaces ("H~a\ b~") inside a subscript.
+	// The backslash is dropped and a regular space is rendered.
+	EscapedSpaces bool
+
+	// InlineMarkdown parses the content of a subscript with the other inline parsers
+	// (emphasis, code spans, links, ...) instead of emitting it as raw text.
+	InlineMarkdown bool
+
+	// WhitespaceFlanking resolves single tildes with whitespace-only left/right-flanking rules through a
+	// parser.DelimiterProcessor, instead of scanning ahead to the next tilde.
+	WhitespaceFlanking bool
+
+	// TildeMode selects whether the extension also owns double tildes (strikethrough).
+	TildeMode TildeMode
+
+	// IsSpace decides which characters count as whitespace, both inside a subscript and before
+	// or after a tilde. A nil IsSpace means unicode.IsSpace.
+	IsSpace func(r rune) bool
+
+	// Stacked selects how a subscript and a superscript on the same base ("x~i~^2^") are rendered.
+	// It only applies to the Scripts extension.
+	Stacked StackStyle
+
+	// Chemistry replaces code spans starting with "ce:" ("`ce:H2SO4`") by chemical formulas.
+	Chemistry bool
+
+	// ChemBlocks replaces fenced code blocks tagged "chem" by reactions.
+	ChemBlocks bool
+
+	// ValidateFormulas reports problems in chemical formulas written with subscripts ("C~6~H~12~0~6~")
+	// as diagnostics, read with Diagnostics.
+	ValidateFormulas bool
+
+	// UnicodeSubscripts renders subscripts as Unicode subscript characters instead of <sub> elements.
+	UnicodeSubscripts bool
+
+	// UnicodeFallback writes subscripts without a Unicode form. A nil UnicodeFallback means FallbackLaTeX.
+	UnicodeFallback UnicodeFallback
+
+	// UnicodeInput parses Unicode subscript characters in text ("H₂O") as subscripts.
+	UnicodeInput bool
+
+	// HTMLInput parses balanced raw HTML <sub></sub> pairs as subscripts.
+	HTMLInput bool
+
+	// TextStyle selects how SubscriptTextRenderer writes subscripts in plain text.
+	TextStyle TextStyle
+
+	// LaTeXMath selects when the LaTeX renderers write subscripts and superscripts in math mode.
+	LaTeXMath LaTeXMathMode
+
+	// OutputFormat selects the renderer the extension registers for Subscript nodes.
+	OutputFormat OutputFormat
+
+	// FormulaMetadata attaches the molar mass and Hill notation of valid chemical formulas as attributes.
+	FormulaMetadata bool
+
+	// Strikethrough tells the parser whether another parser (usually Goldmark's strikethrough
+	// extension) handles the tildes that do not form a subscript.
+	Strikethrough StrikethroughPresence
+}
+
+// StrikethroughPresence tells the subscript parser whether a strikethrough parser is installed.
+type StrikethroughPresence int
+
+const (
+	// StrikethroughAuto detects whether another inline parser for '~' is registered with the Goldmark
+	// parser the extension was added to. Parsers created with NewSubscriptParser cannot detect it and
+	// assume a strikethrough parser is installed. This is the default.
+	StrikethroughAuto StrikethroughPresence = iota
+
+	// StrikethroughPresent assumes a strikethrough parser is installed.
+	StrikethroughPresent
+
+	// StrikethroughAbsent assumes no strikethrough parser is installed.
+	StrikethroughAbsent
+)
+
+// OutputFormat selects the renderer the extension registers for Subscript nodes, for a goldmark renderer
+// that produces something other than HTML.
+type OutputFormat int
+
+const (
+	// OutputHTML renders subscripts with SubscriptHTMLRenderer, or SubscriptUnicodeRenderer with
+	// WithUnicodeSubscripts. This is the default.
+	OutputHTML OutputFormat = iota
+
+	// OutputLaTeX renders subscripts with SubscriptLaTeXRenderer, superscripts with SuperscriptLaTeXRenderer
+	// and stacked scripts with SubSuperscriptLaTeXRenderer.
+	OutputLaTeX
+
+	// OutputTypst renders subscripts with SubscriptTypstRenderer.
+	OutputTypst
+
+	// OutputAsciiDoc renders subscripts with SubscriptAsciiDocRenderer.
+	OutputAsciiDoc
+
+	// OutputJira renders subscripts with SubscriptJiraRenderer.
+	OutputJira
+
+	// OutputRST renders subscripts with SubscriptRSTRenderer.
+	OutputRST
+
+	// OutputOrg renders subscripts with SubscriptOrgRenderer.
+	OutputOrg
+
+	// OutputBBCode renders subscripts with SubscriptBBCodeRenderer.
+	OutputBBCode
+)
+
+// String returns the name of the output format constant, such as "OutputLaTeX".
+func (f OutputFormat) String() string {
+	switch f {
+	case OutputHTML:
+		return "OutputHTML"
+	case OutputLaTeX:
+		return "OutputLaTeX"
+	case OutputTypst:
+		return "OutputTypst"
+	case OutputAsciiDoc:
+		return "OutputAsciiDoc"
+	case OutputJira:
+		return "OutputJira"
+	case OutputRST:
+		return "OutputRST"
+	case OutputOrg:
+		return "OutputOrg"
+	case OutputBBCode:
+		return "OutputBBCode"
+	}
+	return "OutputFormat(" + strconv.Itoa(int(f)) + ")"
+}
+
+// TildeMode selects how single and double tildes are parsed.
+type TildeMode int
+
+const (
+	// TildeModeSubscript only parses subscripts; double tildes are left to Goldmark's strikethrough
+	// extension. This is the default of NewSubscript.
+	TildeModeSubscript TildeMode = iota
+
+	// TildeModeGFM parses both subscripts and strikethroughs and stays compatible with GitHub Flavored
+	// Markdown: a pair of single tildes that cannot be a subscript is a strikethrough, as it is on GitHub.
+	// This is the default of NewTilde.
+	TildeModeGFM
+
+	// TildeModePandoc parses both subscripts and strikethroughs by length, as Pandoc does: a pair of
+	// single tildes is a subscript or literal text, a pair of double tildes is a strikethrough.
+	TildeModePandoc
+)
+
+// ownsStrikethrough reports whether the mode parses double tildes itself.
+func (m TildeMode) ownsStrikethrough() bool {
+	return m == TildeModeGFM || m == TildeModePandoc
+}
+
+// NewConfig returns a Config with the default settings.
+//
+// The default attribute filter is a copy of html.GlobalAttributeFilter.
+func NewConfig() Config {
+	return Config{
+		ParserPriority:   100,
+		RendererPriority: 100,
+		AttributeFilter:  html.GlobalAttributeFilter.Extend(),
+		EscapedSpaces:    true,
+		IsSpace:          unicode.IsSpace,
+	}
+}
+
+// newConfig returns a Config with the default settings changed by opts.
+func newConfig(opts []SubscriptOption) Config {
+	config := NewConfig()
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// newHTMLConfig returns the goldmark HTML settings of an HTML renderer configured by config, and a private copy
+// of its attribute filter.
+func newHTMLConfig(config Config) (html.Config, util.BytesFilter) {
+	c := html.NewConfig()
+	for _, opt := range config.HTMLOptions {
+		opt.SetHTMLOption(&c)
+	}
+	if config.AttributeFilter == nil {
+		return c, nil
+	}
+	return c, config.AttributeFilter.Extend()
+}
+
+// SubscriptOption configures the subscript extension.
+type SubscriptOption func(*Config)
+
+// WithParserPriority sets the priority of the subscript inline parser.
+//
+// The default priority (100) runs the subscript parser before Goldmark's strikethrough parser (500).
+func WithParserPriority(priority int) SubscriptOption {
+	return func(c *Config) {
+		c.ParserPriority = priority
+	}
+}
+
+// WithRendererPriority sets the priority of the subscript HTML renderer, and of the other renderers the extension
+// registers (such as the strikethrough renderer of NewTilde).
+func WithRendererPriority(priority int) SubscriptOption {
+	return func(c *Config) {
+		c.RendererPriority = priority
+	}
+}
+
+// WithHTMLOptions passes Goldmark HTML renderer options (e.g. html.WithXHTML()) to the subscript HTML renderer.
+func WithHTMLOptions(opts ...html.Option) SubscriptOption {
+	return func(c *Config) {
+		// Always copy, so configurations never share a backing array.
+		c.HTMLOptions = append(c.HTMLOptions[:len(c.HTMLOptions):len(c.HTMLOptions)], opts...)
+	}
+}
+
+// WithAttributeFilter sets the attribute names which <sub> elements can have.
+func WithAttributeFilter(filter util.BytesFilter) SubscriptOption {
+	return func(c *Config) {
+		c.AttributeFilter = filter
+	}
+}
+
+// WithAllowLineStart allows subscripts at the beginning of a line (e.g. "~2~O").
+//
+// Without this option a tilde at the beginning of a line is left to the strikethrough parser.
+func WithAllowLineStart() SubscriptOption {
+	return func(c *Config) {
+		c.AllowLineStart = true
+	}
+}
+
+// WithAllowAfterWhitespace allows subscripts directly after whitespace (e.g. "H ~2~O").
+//
+// Without this option a tilde that follows whitespace is left to the strikethrough parser.
+func WithAllowAfterWhitespace() SubscriptOption {
+	return func(c *Config) {
+		c.AllowAfterWhitespace = true
+	}
+}
+
+// WithEscapedSpaces enables or disables backslash-escaped spaces inside subscripts (enabled by default).
+//
+// With escaped spaces enabled, "T~max\ value~" is rendered as "T<sub>max value</sub>", as in Pandoc and
+// markdown-it-sub. Unescaped spaces are never allowed inside a subscript.
+func WithEscapedSpaces(enabled bool) SubscriptOption {
+	return func(c *Config) {
+		c.EscapedSpaces = enabled
+	}
+}
+
+// WithInlineMarkdown parses the content of subscripts as inline markdown.
+//
+// By default the content of a subscript is emitted as raw text. With this option "x~*i*~" is rendered as
+// "x<sub><em>i</em></sub>", and code spans, links and other inline syntax work inside subscripts too.
+func WithInlineMarkdown() SubscriptOption {
+	return func(c *Config) {
+		c.InlineMarkdown = true
+	}
+}
+
+// WithWhitespaceFlanking resolves single tildes with left/right-flanking rules that only look at whitespace.
+//
+// By default the parser scans ahead from each opening tilde to the next tilde on the line. With this option every
+// single tilde becomes a delimiter and pairs are resolved the way emphasis is, once the whole block has been parsed:
+// a pair becomes a subscript when its opener may start a subscript and the content between them is valid subscript
+// content; any other pair of single tildes becomes a strikethrough. Runs of two tildes are still left to the
+// strikethrough parser.
+//
+// Unlike the CommonMark flanking rules of emphasis, punctuation next to a tilde is ignored, so "x~(2)~" is a
+// subscript. Since pairs are resolved from left to right and a run of two tildes is a single delimiter, some input
+// parses differently than with the default parser: "~H~2~O~" becomes "<del>H</del>2<sub>O</sub>" and "x~a~~b~"
+// becomes "x<del>a~~b</del>". Without WithInlineMarkdown, a pair across an unclosed "[" is not a subscript
+// ("x~a[~"), because the link parser still owns the bracket.
+func WithWhitespaceFlanking() SubscriptOption {
+	return func(c *Config) {
+		c.WhitespaceFlanking = true
+	}
+}
+
+// WithSpacePolicy sets which characters count as whitespace (unicode.IsSpace by default).
+//
+// Whitespace is not allowed inside a subscript, and decides whether a tilde can open or close a span. For example,
+// to allow non-breaking spaces inside subscripts:
+//
+//	subscript.WithSpacePolicy(func(r rune) bool {
+//		return r != '\u00a0' && unicode.IsSpace(r)
+//	})
+func WithSpacePolicy(isSpace func(r rune) bool) SubscriptOption {
+	return func(c *Config) {
+		c.IsSpace = isSpace
+	}
+}
+
+// WithTildeMode selects how single and double tildes are parsed.
+//
+// With TildeModeGFM or TildeModePandoc the extension parses "~~text~~" itself, with the same flanking rules as
+// WithWhitespaceFlanking, and Goldmark's strikethrough extension is no longer needed. NewTilde uses TildeModeGFM by default.
+func WithTildeMode(mode TildeMode) SubscriptOption {
+	return func(c *Config) {
+		c.TildeMode = mode
+	}
+}
+
+// WithStrikethrough tells the parser whether a strikethrough parser is installed, instead of detecting it.
+//
+// Without a strikethrough parser nothing else can make use of a tilde, so subscripts are also allowed at the
+// beginning of a line and after whitespace (including at the start of a table cell), and a pair of single
+// tildes that cannot be a subscript is left as literal text instead of becoming a strikethrough.
+func WithStrikethrough(presence StrikethroughPresence) SubscriptOption {
+	return func(c *Config) {
+		c.Strikethrough = presence
+	}
+}
+
+// WithStackedScripts groups a subscript and a superscript on the same base ("x~i~^2^" or "x^2^~i~") into a
+// SubSuperscript node, rendered as selected by style. It only applies to the Scripts extension.
+func WithStackedScripts(style StackStyle) SubscriptOption {
+	return func(c *Config) {
+		c.Stacked = style
+	}
+}
+
+// WithChemistry enables chemical formulas written as code spans with the "ce:" prefix.
+//
+// "`ce:C6H12O6`" is rendered as "C<sub>6</sub>H<sub>12</sub>O<sub>6</sub>" inside a <span class="chem">: counts become
+// subscripts, charges ("SO4^2-", "Na+") become superscripts and hydrate dots ("CuSO4.5H2O") become middle dots.
+//
+// Fenced code blocks tagged "chem" are enabled separately with WithChemBlocks.
+func WithChemistry() SubscriptOption {
+	return func(c *Config) {
+		c.Chemistry = true
+	}
+}
+
+// WithChemBlocks enables reactions written in fenced code blocks tagged "chem", one per line.
+//
+// "2H2 + O2 -> 2H2O" is rendered with the formulas of WithChemistry, "+" as an operator, "->" and "<=>" as
+// arrows and electrons ("e-") as "e<sup>−</sup>" (see ChemBlockHTMLRenderer). Existing "chem" blocks are
+// left alone unless this option is set.
+func WithChemBlocks() SubscriptOption {
+	return func(c *Config) {
+		c.ChemBlocks = true
+	}
+}
+
+// WithFormulaValidation enables the formula validator (see NewFormulaValidator).
+//
+// Runs of text and subscripts that look like chemical formulas are checked against the periodic table and
+// for numeric subscripts. Problems are reported as diagnostics in the parser.Context, read with Diagnostics;
+// the rendered output is not changed.
+func WithFormulaValidation() SubscriptOption {
+	return func(c *Config) {
+		c.ValidateFormulas = true
+	}
+}
+
+// WithFormulaMetadata attaches the molar mass and Hill notation of chemical formulas as attributes
+// (see NewFormulaMetadataTransformer).
+//
+// Runs of text and subscripts that form a valid formula ("C~6~H~12~O~6~") are wrapped in a <span class="chem">
+// with title, data-formula and data-molar-mass attributes; "ce:" formulas get the same attributes.
+func WithFormulaMetadata() SubscriptOption {
+	return func(c *Config) {
+		c.FormulaMetadata = true
+	}
+}
+
+// WithUnicodeSubscripts renders subscripts as Unicode subscript characters ("H~2~O" → "H₂O") instead of
+// <sub> elements (see SubscriptUnicodeRenderer).
+//
+// Subscripts with a character that has no subscript form are written with fallback; a nil fallback means
+// FallbackLaTeX ("_x", "_{xyz}").
+func WithUnicodeSubscripts(fallback UnicodeFallback) SubscriptOption {
+	return func(c *Config) {
+		c.UnicodeSubscripts = true
+		c.UnicodeFallback = fallback
+	}
+}
+
+// WithUnicodeInput parses runs of Unicode subscript characters in text ("H₂O", as pasted from word
+// processors) as subscripts, so they are rendered like "H~2~O" (see NewUnicodeSubscriptTransformer).
+func WithUnicodeInput() SubscriptOption {
+	return func(c *Config) {
+		c.UnicodeInput = true
+	}
+}
+
+// WithHTMLInput parses balanced raw HTML <sub></sub> pairs in a paragraph ("H<sub>2</sub>O") as subscripts,
+// so all renderers treat them like "H~2~O" and they are rendered even without html.WithUnsafe
+// (see NewRawHTMLSubscriptTransformer).
+func WithHTMLInput() SubscriptOption {
+	return func(c *Config) {
+		c.HTMLInput = true
+	}
+}
+
+// WithTextStyle sets how SubscriptTextRenderer writes subscripts in plain text: TextInline ("H2O", the
+// default), TextUnderscore ("H_2O") or TextUnicode ("H₂O").
+func WithTextStyle(style TextStyle) SubscriptOption {
+	return func(c *Config) {
+		c.TextStyle = style
+	}
+}
+
+// WithOutputFormat registers the renderers for format instead of the HTML renderers, at the renderer
+// priority, so a goldmark renderer that produces that format gets subscripts from the extension.
+//
+// Only OutputLaTeX has renderers for superscripts and stacked scripts, and no format other than OutputHTML
+// has renderers for chemical formulas: the extensions panic when they are added to a goldmark instance with
+// superscripts or WithChemistry, WithChemBlocks or WithFormulaMetadata and a format that cannot render them.
+// With a format other than OutputHTML, TildeModeGFM leaves strikethroughs to the renderer of that format.
+func WithOutputFormat(format OutputFormat) SubscriptOption {
+	return func(c *Config) {
+		c.OutputFormat = format
+	}
+}
+
+// WithLaTeXMath selects when the LaTeX renderers write subscripts in math mode ("$_{i}$") instead of
+// text mode ("\textsubscript{i}"): LaTeXMathNever (the default), LaTeXMathIndex or LaTeXMathAlways.
+func WithLaTeXMath(mode LaTeXMathMode) SubscriptOption {
+	return func(c *Config) {
+		c.LaTeXMath = mode
+	}
+}
@@ -0,0 +1,82 @@
+package subscript
+
+import (
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// SubscriptOrgRenderer renders Subscript nodes to Org mode ("H~2~O" becomes "H_{2}O"), for a goldmark
+// renderer that produces Org. The extension registers it with WithOutputFormat(OutputOrg).
+//
+// Org only reads "_{...}" as a subscript after a character that is not whitespace, so at the start of a line,
+// after whitespace or next to a node that is not text, a zero-width space (U+200B) is written before it. The
+// braced form is always used, so the subscript can hold any text. Braces in the content are kept
+// when they are balanced and written as the entities \lbrace{} and \rbrace{} otherwise; backslashes are
+// written as \backslash{}. Content that is not plain text (see WithInlineMarkdown) is left to the other
+// renderers between the braces.
+type SubscriptOrgRenderer struct{}
+
+// NewSubscriptOrgRenderer returns a new SubscriptOrgRenderer.
+func NewSubscriptOrgRenderer() renderer.NodeRenderer {
+	return &SubscriptOrgRenderer{}
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *SubscriptOrgRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindSubscript, r.renderSubscript)
+}
+
+func (r *SubscriptOrgRenderer) renderSubscript(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		if before, _ := adjacentRunes(n, source); before == 0 || unicode.IsSpace(before) {
+			_, _ = w.WriteString("\u200b")
+		}
+	}
+	if !isTextOnly(n) {
+		if entering {
+			_, _ = w.WriteString("_{")
+		} else {
+			_ = w.WriteByte('}')
+		}
+		return ast.WalkContinue, nil
+	}
+	if entering {
+		_, _ = w.WriteString("_{")
+		writeOrg(w, appendPlainText(nil, n, source))
+		_ = w.WriteByte('}')
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// writeOrg writes s for use in an Org subscript, keeping balanced braces.
+func writeOrg(w util.BufWriter, s []byte) {
+	balanced := make([]bool, len(s))
+	var open []int
+	for i, b := range s {
+		switch b {
+		case '{':
+			open = append(open, i)
+		case '}':
+			if len(open) > 0 {
+				balanced[open[len(open)-1]], balanced[i] = true, true
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	for i, b := range s {
+		switch {
+		case b == '\\':
+			_, _ = w.WriteString(`\backslash{}`)
+		case b == '{' && !balanced[i]:
+			_, _ = w.WriteString(`\lbrace{}`)
+		case b == '}' && !balanced[i]:
+			_, _ = w.WriteString(`\rbrace{}`)
+		default:
+			_ = w.WriteByte(b)
+		}
+	}
+}
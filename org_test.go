@@ -0,0 +1,20 @@
+package subscript
+
+import (
+	"testing"
+)
+
+func TestSubscriptOrgRenderer(t *testing.T) {
+	testOutputFormat(t, NewSubscriptOrgRenderer(), nil, []outputTestCase{
+		{`H~2~O and x~i+1~`, `H_{2}O and x_{i+1}`},
+		{`T~max\ value~`, `T_{max value}`},
+		{`x~{a}~ and x~a}~ and x~\\~`, `x_{{a}} and x_{a\rbrace{}} and x_{\backslash{}}`},
+	})
+
+	// Org needs a character that is not whitespace before the underscore
+	testOutputFormat(t, NewSubscriptOrgRenderer(), []SubscriptOption{WithAllowLineStart(), WithAllowAfterWhitespace()},
+		[]outputTestCase{
+			{`~2~O and x ~2~`, "\u200b_{2}O and x \u200b_{2}"},
+			{`*a*~2~`, "*a*\u200b_{2}"},
+		})
+}
@@ -0,0 +1,140 @@
+package subscript
+
+import (
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// TextStyle selects how subscripts are written in plain text.
+type TextStyle int
+
+const (
+	// TextInline writes subscripts as normal text: "H~2~O" becomes "H2O". This is the default.
+	TextInline TextStyle = iota
+
+	// TextUnderscore writes subscripts as "_x", or "_{xyz}" for longer content: "H~2~O" becomes "H_2O".
+	TextUnderscore
+
+	// TextUnicode writes subscripts with Unicode subscript characters where possible: "H~2~O" becomes
+	// "H₂O"; subscripts without a Unicode form are written as with TextUnderscore.
+	TextUnicode
+)
+
+// appendStyledSubscript appends the subscript content in the given style to buf.
+func appendStyledSubscript(buf []byte, content []byte, style TextStyle) []byte {
+	switch style {
+	case TextUnderscore:
+		return append(buf, FallbackLaTeX(content)...)
+	case TextUnicode:
+		if sub, ok := appendUnicodeSubscript(buf, content); ok {
+			return sub
+		}
+		return append(buf, FallbackLaTeX(content)...)
+	}
+	return append(buf, content...)
+}
+
+// ExtractText returns the plain text of n and its descendants, for search indexes, summaries and
+// <meta> descriptions. Subscripts are kept in the given style, so "H~2~O" is extracted as "H2O" with
+// TextInline rather than dropped or split into "H", "2" and "O".
+//
+// Backslash escapes and character references are resolved ("a\*b &amp; c" is extracted as "a*b & c"), except
+// in code. Blocks and line breaks are separated by newlines, code blocks keep their lines and raw HTML is
+// left out.
+func ExtractText(n ast.Node, source []byte, style TextStyle) []byte {
+	var buf []byte
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if c.Type() == ast.TypeBlock && len(buf) > 0 && buf[len(buf)-1] != '\n' {
+			buf = append(buf, '\n')
+		}
+		switch c := c.(type) {
+		case *Node:
+			buf = appendStyledSubscript(buf, appendPlainText(nil, c, source), style)
+			return ast.WalkSkipChildren, nil
+		case *SubSuperscriptNode:
+			buf = append(buf, c.Base.Value(source)...)
+		case *ast.Text:
+			buf = appendTextValue(buf, c, source)
+			if c.SoftLineBreak() || c.HardLineBreak() {
+				buf = append(buf, '\n')
+			}
+		case *ast.String:
+			buf = append(buf, c.Value...)
+		case *ast.AutoLink:
+			buf = append(buf, c.Label(source)...)
+		case *ast.RawHTML, *ast.HTMLBlock:
+			return ast.WalkSkipChildren, nil
+		default:
+			if c.Type() == ast.TypeBlock && !c.HasChildren() {
+				lines := c.Lines()
+				for i := 0; i < lines.Len(); i++ {
+					line := lines.At(i)
+					buf = append(buf, line.Value(source)...)
+				}
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return buf
+}
+
+// appendPlainText appends the text below n to buf, like appendNodeText, but resolves backslash escapes and
+// character references in text nodes as the HTML renderer does ("a\*b" is appended as "a*b").
+func appendPlainText(buf []byte, n ast.Node, source []byte) []byte {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch t := c.(type) {
+		case *ast.Text:
+			buf = appendTextValue(buf, t, source)
+		case *ast.String:
+			buf = append(buf, t.Value...)
+		default:
+			buf = appendPlainText(buf, c, source)
+		}
+	}
+	return buf
+}
+
+// appendTextValue appends the value of t to buf with backslash escapes and character references resolved,
+// unless t is raw text such as the content of a code span.
+func appendTextValue(buf []byte, t *ast.Text, source []byte) []byte {
+	value := t.Segment.Value(source)
+	if t.IsRaw() {
+		return append(buf, value...)
+	}
+	return append(buf, util.UnescapePunctuations(util.ResolveNumericReferences(util.ResolveEntityNames(value)))...)
+}
+
+// SubscriptTextRenderer renders Subscript nodes as plain text, for plain-text renderers: "H~2~O" is
+// written as "H2O", "H_2O" or "H₂O" depending on the style set with WithTextStyle. The output is not
+// escaped.
+type SubscriptTextRenderer struct {
+	style TextStyle
+}
+
+// NewSubscriptTextRenderer returns a new SubscriptTextRenderer with the given options.
+func NewSubscriptTextRenderer(opts ...SubscriptOption) renderer.NodeRenderer {
+	return newSubscriptTextRenderer(newConfig(opts))
+}
+
+func newSubscriptTextRenderer(config Config) *SubscriptTextRenderer {
+	return &SubscriptTextRenderer{
+		style: config.TextStyle,
+	}
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *SubscriptTextRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindSubscript, r.renderSubscript)
+}
+
+func (r *SubscriptTextRenderer) renderSubscript(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		_, _ = w.Write(appendStyledSubscript(nil, appendPlainText(nil, n, source), r.style))
+	}
+	return ast.WalkSkipChildren, nil
+}
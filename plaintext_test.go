@@ -0,0 +1,80 @@
+package subscript
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+func TestExtractText(t *testing.T) {
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			NewSubscript(),
+		),
+	)
+
+	source := []byte("# Water\n\nH~2~O is *water*,\nT~max~ is x~a<b~.\n\n- CO~2~\n\n<div>raw</div>\n\n```\ncode\n```\n")
+	testCases := []struct {
+		style TextStyle
+		text  string
+	}{
+		{TextInline, "Water\nH2O is water,\nTmax is xa<b.\nCO2\ncode\n"},
+		{TextUnderscore, "Water\nH_2O is water,\nT_{max} is x_{a<b}.\nCO_2\ncode\n"},
+		{TextUnicode, "Water\nH₂O is water,\nTₘₐₓ is x_{a<b}.\nCO₂\ncode\n"},
+	}
+
+	doc := md.Parser().Parse(text.NewReader(source))
+	for _, tc := range testCases {
+		if got := string(ExtractText(doc, source, tc.style)); got != tc.text {
+			t.Errorf("style %d: got %q, want %q", tc.style, got, tc.text)
+		}
+	}
+
+	// Escapes and character references are resolved as in HTML, except in code spans
+	source = []byte("a\\*b &amp; c, x~&amp;~ and y~a\\ b~ and `a\\*b &amp;`")
+	want := "a*b & c, x& and ya b and a\\*b &amp;"
+	if got := string(ExtractText(md.Parser().Parse(text.NewReader(source)), source, TextInline)); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSubscriptTextRenderer(t *testing.T) {
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			NewSubscript(),
+		),
+	)
+
+	testCases := []struct {
+		style TextStyle
+		text  string
+	}{
+		{TextInline, "H2O and Tmax and x&"},
+		{TextUnderscore, "H_2O and T_{max} and x_&"},
+		{TextUnicode, "H₂O and Tₘₐₓ and x_&"},
+	}
+
+	source := []byte("H~2~O and T~max~ and x~&amp;~")
+	doc := md.Parser().Parse(text.NewReader(source))
+	for _, tc := range testCases {
+		// Only text and subscripts are written, so the test renders them with the subscript renderer
+		// and a renderer for text nodes
+		r := renderer.NewRenderer(renderer.WithNodeRenderers(
+			util.Prioritized(testMarkdownRenderer{}, 1000),
+			util.Prioritized(NewSubscriptTextRenderer(WithTextStyle(tc.style)), 100),
+		))
+		var buf bytes.Buffer
+		if err := r.Render(&buf, source, doc); err != nil {
+			t.Fatal(err)
+		}
+		if got := string(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))); got != tc.text {
+			t.Errorf("style %d: got %q, want %q", tc.style, got, tc.text)
+		}
+	}
+}
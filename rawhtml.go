@@ -0,0 +1,81 @@
+package subscript
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// rawHTMLSubscriptTransformer replaces balanced raw HTML <sub></sub> pairs by Subscript nodes.
+type rawHTMLSubscriptTransformer struct{}
+
+// NewRawHTMLSubscriptTransformer returns an ASTTransformer that replaces raw HTML <sub> and </sub> tags
+// around inline content ("H<sub>2</sub>O") by a Subscript node holding that content, so all renderers
+// treat them as subscripts. Only pairs with the same parent and a <sub> tag without attributes are
+// replaced; pairs may be nested. Since the tags are no longer raw HTML, the output is the same whether
+// or not html.WithUnsafe is set.
+func NewRawHTMLSubscriptTransformer() parser.ASTTransformer {
+	return rawHTMLSubscriptTransformer{}
+}
+
+// Transform implements parser.ASTTransformer.
+func (rawHTMLSubscriptTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	var parents []ast.Node
+	seen := map[ast.Node]bool{}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if raw, ok := n.(*ast.RawHTML); ok && entering && !seen[n.Parent()] && isSubscriptTag(raw, source, false) {
+			parents = append(parents, n.Parent())
+			seen[n.Parent()] = true
+		}
+		return ast.WalkContinue, nil
+	})
+	for _, parent := range parents {
+		replaceSubscriptTags(parent, source)
+	}
+}
+
+// replaceSubscriptTags replaces the balanced <sub></sub> pairs among the children of parent.
+func replaceSubscriptTags(parent ast.Node, source []byte) {
+	var openers []ast.Node
+	for c := parent.FirstChild(); c != nil; {
+		next := c.NextSibling()
+		if raw, ok := c.(*ast.RawHTML); ok {
+			switch {
+			case isSubscriptTag(raw, source, false):
+				openers = append(openers, c)
+			case isSubscriptTag(raw, source, true) && len(openers) > 0:
+				opener := openers[len(openers)-1]
+				openers = openers[:len(openers)-1]
+				node := NewSubscriptNode()
+				for content := opener.NextSibling(); content != c; {
+					following := content.NextSibling()
+					node.AppendChild(node, content)
+					content = following
+				}
+				parent.ReplaceChild(parent, opener, node)
+				parent.RemoveChild(parent, c)
+			}
+		}
+		c = next
+	}
+}
+
+// isSubscriptTag reports whether raw is a <sub> tag without attributes, or a </sub> tag if closing is set.
+func isSubscriptTag(raw *ast.RawHTML, source []byte, closing bool) bool {
+	if raw.Segments.Len() != 1 {
+		return false
+	}
+	segment := raw.Segments.At(0)
+	tag := segment.Value(source)
+	prefix := "<sub"
+	if closing {
+		prefix = "</sub"
+	}
+	if len(tag) < len(prefix)+1 || !bytes.EqualFold(tag[:len(prefix)], []byte(prefix)) {
+		return false
+	}
+	return len(bytes.TrimSpace(tag[len(prefix):len(tag)-1])) == 0 && tag[len(tag)-1] == '>'
+}
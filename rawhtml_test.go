@@ -0,0 +1,66 @@
+package subscript
+
+import (
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/testutil"
+)
+
+func TestHTMLInput(t *testing.T) {
+	mdTest := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			NewSubscript(WithHTMLInput()),
+		),
+	)
+
+	testCases := []TestCase{
+		{
+			desc: "HTML input: sub tags become subscripts",
+			md:   "H<sub>2</sub>O and H~2~O",
+			html: `<p>H<sub>2</sub>O and H<sub>2</sub>O</p>`,
+		},
+		{
+			desc: "HTML input: content is markdown",
+			md:   "Text<sub>*word* and `x`</sub>",
+			html: `<p>Text<sub><em>word</em> and <code>x</code></sub></p>`,
+		},
+		{
+			desc: "HTML input: nested and upper case tags",
+			md:   "x<SUB>i<sub>2</sub></SUB>",
+			html: `<p>x<sub>i<sub>2</sub></sub></p>`,
+		},
+		{
+			desc: "HTML input: unbalanced tags stay raw HTML",
+			md:   "a</sub> and <sub>b",
+			html: `<p>a<!-- raw HTML omitted --> and <!-- raw HTML omitted -->b</p>`,
+		},
+		{
+			desc: "HTML input: tags with attributes stay raw HTML",
+			md:   `<sub onclick="x()">a</sub>`,
+			html: `<p><!-- raw HTML omitted -->a<!-- raw HTML omitted --></p>`,
+		},
+		{
+			desc: "HTML input: pairs must have the same parent",
+			md:   "<sub>a*b</sub>*",
+			html: `<p><!-- raw HTML omitted -->a<em>b<!-- raw HTML omitted --></em></p>`,
+		},
+		{
+			desc: "HTML input: raw HTML inside stays omitted",
+			md:   "x<sub><script>alert(1)</script></sub>",
+			html: `<p>x<sub><!-- raw HTML omitted -->alert(1)<!-- raw HTML omitted --></sub></p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(mdTest, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+}
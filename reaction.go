@@ -0,0 +1,304 @@
+package subscript
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// KindChemBlock is a NodeKind of the ChemBlock node.
+var KindChemBlock = ast.NewNodeKind("ChemBlock")
+
+// ChemBlockNode is a fenced code block tagged "chem". Its children are Reaction nodes, one per
+// non-blank line.
+type ChemBlockNode struct {
+	ast.BaseBlock
+}
+
+// Kind implements ast.Node.Kind.
+func (*ChemBlockNode) Kind() ast.NodeKind {
+	return KindChemBlock
+}
+
+// Dump implements ast.Node.Dump.
+func (n *ChemBlockNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// NewChemBlockNode returns a new ChemBlock node.
+func NewChemBlockNode() *ChemBlockNode {
+	return &ChemBlockNode{}
+}
+
+// KindReaction is a NodeKind of the Reaction node.
+var KindReaction = ast.NewNodeKind("Reaction")
+
+// ReactionNode is a line of a chem block ("2H2 + O2 -> 2H2O").
+//
+// Its children are ReactionSide nodes separated by ReactionOperator nodes for the arrows.
+type ReactionNode struct {
+	ast.BaseBlock
+}
+
+// Kind implements ast.Node.Kind.
+func (*ReactionNode) Kind() ast.NodeKind {
+	return KindReaction
+}
+
+// Dump implements ast.Node.Dump.
+func (n *ReactionNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// NewReactionNode returns a new Reaction node for the given line.
+func NewReactionNode(line []byte) *ReactionNode {
+	n := &ReactionNode{}
+	appendReaction(n, line)
+	return n
+}
+
+// KindReactionSide is a NodeKind of the ReactionSide node.
+var KindReactionSide = ast.NewNodeKind("ReactionSide")
+
+// ReactionSideNode is one side of a reaction: the reactants or the products. Its children are coefficients
+// (strings), Formula nodes, electrons ("e" followed by a Superscript node) and "+" ReactionOperator nodes.
+type ReactionSideNode struct {
+	ast.BaseInline
+}
+
+// Kind implements ast.Node.Kind.
+func (*ReactionSideNode) Kind() ast.NodeKind {
+	return KindReactionSide
+}
+
+// Dump implements ast.Node.Dump.
+func (n *ReactionSideNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// NewReactionSideNode returns a new ReactionSide node.
+func NewReactionSideNode() *ReactionSideNode {
+	return &ReactionSideNode{}
+}
+
+// KindReactionOperator is a NodeKind of the ReactionOperator node.
+var KindReactionOperator = ast.NewNodeKind("ReactionOperator")
+
+// ReactionOperatorNode is an operator of a reaction: "+" or an arrow ("->", "<=>").
+type ReactionOperatorNode struct {
+	ast.BaseInline
+
+	// Operator is the operator as written.
+	Operator []byte
+}
+
+// Kind implements ast.Node.Kind.
+func (*ReactionOperatorNode) Kind() ast.NodeKind {
+	return KindReactionOperator
+}
+
+// Dump implements ast.Node.Dump.
+func (n *ReactionOperatorNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{
+		"Operator": string(n.Operator),
+	}, nil)
+}
+
+// NewReactionOperatorNode returns a new ReactionOperator node.
+func NewReactionOperatorNode(operator []byte) *ReactionOperatorNode {
+	return &ReactionOperatorNode{
+		Operator: operator,
+	}
+}
+
+// IsArrow reports whether the operator is an arrow.
+func (n *ReactionOperatorNode) IsArrow() bool {
+	_, ok := reactionArrows[string(n.Operator)]
+	return ok
+}
+
+// reactionArrows maps the arrows of a reaction to the characters they are rendered as.
+var reactionArrows = map[string]string{
+	"->":  "→",
+	"<=>": "⇌",
+}
+
+// chemBlockLanguage is the info string of chem blocks.
+var chemBlockLanguage = []byte("chem")
+
+// chemBlockTransformer replaces fenced code blocks tagged "chem" by ChemBlock nodes.
+type chemBlockTransformer struct{}
+
+// NewChemBlockTransformer returns an ASTTransformer that replaces fenced code blocks tagged "chem" by
+// ChemBlock nodes. Each line is a reaction: formulas are parsed as in "ce:" code spans, "+", "->" and
+// "<=>" become operators and electrons ("e-" or "e^-") are written as "e" with a "−" superscript.
+func NewChemBlockTransformer() parser.ASTTransformer {
+	return chemBlockTransformer{}
+}
+
+// Transform implements parser.ASTTransformer.
+func (chemBlockTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	var blocks []*ast.FencedCodeBlock
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if block, ok := n.(*ast.FencedCodeBlock); ok && entering {
+			if bytes.Equal(block.Language(source), chemBlockLanguage) {
+				blocks = append(blocks, block)
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	for _, block := range blocks {
+		chem := NewChemBlockNode()
+		chem.SetBlankPreviousLines(block.HasBlankPreviousLines())
+		lines := block.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			segment := lines.At(i)
+			line := util.TrimRightSpace(util.TrimLeftSpace(segment.Value(source)))
+			if len(line) > 0 {
+				chem.AppendChild(chem, NewReactionNode(line))
+			}
+		}
+		block.Parent().ReplaceChild(block.Parent(), block, chem)
+	}
+}
+
+// appendReaction appends the sides and arrows of the reaction written on line to n.
+func appendReaction(n *ReactionNode, line []byte) {
+	side := NewReactionSideNode()
+	n.AppendChild(n, side)
+	for _, token := range bytes.Fields(line) {
+		if _, ok := reactionArrows[string(token)]; ok {
+			n.AppendChild(n, NewReactionOperatorNode(token))
+			side = NewReactionSideNode()
+			n.AppendChild(n, side)
+			continue
+		}
+		if side.HasChildren() {
+			side.AppendChild(side, ast.NewString([]byte(" ")))
+		}
+		if bytes.Equal(token, []byte("+")) {
+			side.AppendChild(side, NewReactionOperatorNode(token))
+			continue
+		}
+		// the coefficient is not part of the formula
+		i := 0
+		for i < len(token) && isDigit(token[i]) {
+			i++
+		}
+		if i > 0 {
+			side.AppendChild(side, ast.NewString(token[:i]))
+		}
+		switch {
+		case isElectron(token[i:]):
+			side.AppendChild(side, ast.NewString([]byte("e")))
+			charge := NewSuperscriptNode()
+			charge.AppendChild(charge, ast.NewString([]byte("−")))
+			side.AppendChild(side, charge)
+		case i < len(token):
+			side.AppendChild(side, NewFormulaNode(token[i:]))
+		}
+	}
+}
+
+// isElectron reports whether token is an electron ("e-" or "e^-") in a reaction.
+func isElectron(token []byte) bool {
+	return bytes.Equal(token, []byte("e-")) || bytes.Equal(token, []byte("e^-"))
+}
+
+// ChemBlockHTMLRenderer renders chem blocks to HTML:
+//
+//	<div class="chem-block">
+//	<div class="chem-reaction"><span class="chem-side">…</span> <span class="chem-arrow">→</span> <span class="chem-side">…</span></div>
+//	</div>
+//
+// Formulas are rendered by the Formula renderer and "+" as <span class="chem-op">+</span>.
+type ChemBlockHTMLRenderer struct {
+	html.Config
+	attributeFilter util.BytesFilter
+}
+
+// NewChemBlockHTMLRenderer returns a new ChemBlockHTMLRenderer with the given options.
+func NewChemBlockHTMLRenderer(opts ...SubscriptOption) renderer.NodeRenderer {
+	return newChemBlockHTMLRenderer(newConfig(opts))
+}
+
+func newChemBlockHTMLRenderer(config Config) *ChemBlockHTMLRenderer {
+	r := &ChemBlockHTMLRenderer{}
+	r.Config, r.attributeFilter = newHTMLConfig(config)
+	return r
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *ChemBlockHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindChemBlock, r.renderChemBlock)
+	reg.Register(KindReaction, r.renderReaction)
+	reg.Register(KindReactionSide, r.renderReactionSide)
+	reg.Register(KindReactionOperator, r.renderReactionOperator)
+}
+
+func (r *ChemBlockHTMLRenderer) renderChemBlock(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.writeStartTag(w, "div", "chem-block", n)
+		_ = w.WriteByte('\n')
+	} else {
+		_, _ = w.WriteString("</div>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *ChemBlockHTMLRenderer) renderReaction(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.writeStartTag(w, "div", "chem-reaction", n)
+	} else {
+		_, _ = w.WriteString("</div>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *ChemBlockHTMLRenderer) renderReactionSide(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		if n.PreviousSibling() != nil {
+			_ = w.WriteByte(' ')
+		}
+		r.writeStartTag(w, "span", "chem-side", n)
+	} else {
+		_, _ = w.WriteString("</span>")
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *ChemBlockHTMLRenderer) renderReactionOperator(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	operator := n.(*ReactionOperatorNode)
+	if operator.IsArrow() {
+		_ = w.WriteByte(' ')
+		r.writeStartTag(w, "span", "chem-arrow", n)
+		_, _ = w.WriteString(reactionArrows[string(operator.Operator)])
+	} else {
+		r.writeStartTag(w, "span", "chem-op", n)
+		_, _ = w.Write(util.EscapeHTML(operator.Operator))
+	}
+	_, _ = w.WriteString("</span>")
+	return ast.WalkContinue, nil
+}
+
+func (r *ChemBlockHTMLRenderer) writeStartTag(w util.BufWriter, tag, class string, n ast.Node) {
+	_, _ = w.WriteString("<" + tag + ` class="` + class + `"`)
+	if n.Attributes() != nil {
+		html.RenderAttributes(w, n, r.attributeFilter)
+	}
+	_ = w.WriteByte('>')
+}
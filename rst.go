@@ -0,0 +1,96 @@
+package subscript
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// SubscriptRSTRenderer renders Subscript nodes to reStructuredText with the :sub: role, for a goldmark
+// renderer that produces reStructuredText. The extension registers it with WithOutputFormat(OutputRST).
+//
+// Inline markup must be separated from the surrounding text by whitespace or punctuation, so inside words
+// the role is set off with escaped whitespace, which is removed from the output: "H~2~O" becomes
+// "H\ :sub:`2`\ O". Interpreted text cannot start or end with whitespace, so spaces at either end of the
+// content (from escaped spaces, "x~\ a~") are written outside the role. Backslashes and backquotes in the
+// content are escaped. Content that is not plain text (see WithInlineMarkdown) is left to the other renderers
+// between the backquotes.
+type SubscriptRSTRenderer struct{}
+
+// NewSubscriptRSTRenderer returns a new SubscriptRSTRenderer.
+func NewSubscriptRSTRenderer() renderer.NodeRenderer {
+	return &SubscriptRSTRenderer{}
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *SubscriptRSTRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindSubscript, r.renderSubscript)
+}
+
+func (r *SubscriptRSTRenderer) renderSubscript(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	before, after := adjacentRunes(n, source)
+	start := func() {
+		if !isRSTBoundary(before, "-:/'\"<([{") {
+			_, _ = w.WriteString(`\ `)
+		}
+		_, _ = w.WriteString(":sub:`")
+	}
+	end := func() {
+		_ = w.WriteByte('`')
+		if !isRSTBoundary(after, "-.,:;!?\\/'\")]}>") {
+			_, _ = w.WriteString(`\ `)
+		}
+	}
+	if !isTextOnly(n) {
+		if entering {
+			start()
+		} else {
+			end()
+		}
+		return ast.WalkContinue, nil
+	}
+	if !entering {
+		return ast.WalkSkipChildren, nil
+	}
+	content := appendPlainText(nil, n, source)
+	trimmed := bytes.TrimFunc(content, unicode.IsSpace)
+	if len(trimmed) == 0 {
+		_, _ = w.Write(content)
+		return ast.WalkSkipChildren, nil
+	}
+	lead := content[:bytes.Index(content, trimmed)]
+	trail := content[len(lead)+len(trimmed):]
+	if len(lead) > 0 {
+		_, _ = w.Write(lead)
+		before = ' '
+	}
+	if len(trail) > 0 {
+		after = ' '
+	}
+	start()
+	writeRST(w, trimmed)
+	end()
+	_, _ = w.Write(trail)
+	return ast.WalkSkipChildren, nil
+}
+
+// isRSTBoundary reports whether inline markup can start after, or end before, r: whitespace or one of
+// the given punctuation characters.
+func isRSTBoundary(r rune, punctuation string) bool {
+	return r != 0 && (unicode.IsSpace(r) || strings.ContainsRune(punctuation, r))
+}
+
+// writeRST writes s for use in reStructuredText interpreted text, escaping backslashes and backquotes.
+func writeRST(w util.BufWriter, s []byte) {
+	for _, b := range s {
+		if b == '\\' || b == '`' {
+			_ = w.WriteByte('\\')
+		}
+		_ = w.WriteByte(b)
+	}
+}
@@ -0,0 +1,18 @@
+package subscript
+
+import (
+	"testing"
+)
+
+func TestSubscriptRSTRenderer(t *testing.T) {
+	testOutputFormat(t, NewSubscriptRSTRenderer(), nil, []outputTestCase{
+		{`H~2~O and x~i+1~`, "H\\ :sub:`2`\\ O and x\\ :sub:`i+1`"},
+		{"(x~2~), a-x~2~-b", "(x\\ :sub:`2`), a-x\\ :sub:`2`-b"},
+		{`T~max\ value~`, "T\\ :sub:`max value`"},
+		{"x~a\\`b\\\\~", "x\\ :sub:`a\\`b\\\\`"},
+		{`*a*~2~`, "*a*\\ :sub:`2`"},
+
+		// interpreted text cannot start or end with whitespace
+		{`x~\ a~b and x~a\ ~ and x~\ ~`, "x :sub:`a`\\ b and x\\ :sub:`a`  and x "},
+	})
+}
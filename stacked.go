@@ -0,0 +1,316 @@
+package subscript
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// KindSubSuperscript is a NodeKind of the SubSuperscript node.
+var KindSubSuperscript = ast.NewNodeKind("SubSuperscript")
+
+// SubSuperscriptNode groups a subscript and a superscript written on the same base ("x~i~^2^"),
+// so they can be rendered stacked on top of each other.
+//
+// Its first child is always the subscript (*Node) and its second child the superscript (*SuperscriptNode),
+// whatever order they were written in.
+type SubSuperscriptNode struct {
+	ast.BaseInline
+
+	// SuperscriptFirst reports whether the superscript was written before the subscript ("x^2^~i~").
+	SuperscriptFirst bool
+
+	// Base is the segment of the letter or digit the pair is written on ("x" in "x~i~^2^"), moved out of the text
+	// before the pair. It is only set by the Scripts extension with StackMathML, so that the msubsup element has
+	// a base; renderers that handle SubSuperscript nodes write it before the pair.
+	Base text.Segment
+}
+
+// Kind implements ast.Node.Kind.
+func (*SubSuperscriptNode) Kind() ast.NodeKind {
+	return KindSubSuperscript
+}
+
+// Dump implements ast.Node.Dump.
+func (n *SubSuperscriptNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{
+		"SuperscriptFirst": boolString(n.SuperscriptFirst),
+		"Base":             string(n.Base.Value(source)),
+	}, nil)
+}
+
+// NewSubSuperscriptNode returns a new SubSuperscript node.
+func NewSubSuperscriptNode() *SubSuperscriptNode {
+	return &SubSuperscriptNode{}
+}
+
+// Subscript returns the subscript of the pair.
+func (n *SubSuperscriptNode) Subscript() *Node {
+	sub, _ := n.FirstChild().(*Node)
+	return sub
+}
+
+// Superscript returns the superscript of the pair.
+func (n *SubSuperscriptNode) Superscript() *SuperscriptNode {
+	sup, _ := n.LastChild().(*SuperscriptNode)
+	return sup
+}
+
+func boolString(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+// stackedScriptsTransformer groups adjacent subscripts and superscripts into SubSuperscript nodes.
+type stackedScriptsTransformer struct{}
+
+// NewStackedScriptsTransformer returns an ASTTransformer that groups a subscript directly followed by a
+// superscript, or a superscript directly followed by a subscript, into a SubSuperscript node.
+// A script takes part in at most one pair, so "x~i~^2^~j~" only groups "~i~^2^".
+func NewStackedScriptsTransformer() parser.ASTTransformer {
+	return stackedScriptsTransformer{}
+}
+
+// Transform implements parser.ASTTransformer.
+func (stackedScriptsTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	var pairs [][2]ast.Node
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			next := c.NextSibling()
+			if next != nil && isScriptPair(c, next) {
+				pairs = append(pairs, [2]ast.Node{c, next})
+				c = next
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	for _, pair := range pairs {
+		first, second := pair[0], pair[1]
+		parent := first.Parent()
+		stacked := NewSubSuperscriptNode()
+		parent.InsertBefore(parent, first, stacked)
+		if _, ok := first.(*SuperscriptNode); ok {
+			stacked.SuperscriptFirst = true
+			first, second = second, first
+		}
+		stacked.AppendChild(stacked, first)
+		stacked.AppendChild(stacked, second)
+	}
+}
+
+// stackedBaseTransformer moves the base of each SubSuperscript node out of the text before it.
+//
+// It runs after the other transformers, so the formula transformers still see the base as part of the text.
+type stackedBaseTransformer struct{}
+
+// Transform implements parser.ASTTransformer.
+func (stackedBaseTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if stacked, ok := n.(*SubSuperscriptNode); ok && entering {
+			stacked.Base = takeBase(stacked.PreviousSibling(), source)
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// takeBase removes the last character from prev and returns its segment, if prev is text ending in a letter
+// or digit.
+func takeBase(prev ast.Node, source []byte) text.Segment {
+	t, ok := prev.(*ast.Text)
+	if !ok || t.SoftLineBreak() || t.HardLineBreak() {
+		return text.Segment{}
+	}
+	r, n := utf8.DecodeLastRune(t.Segment.Value(source))
+	if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+		return text.Segment{}
+	}
+	base := t.Segment.WithStart(t.Segment.Stop - n)
+	t.Segment = t.Segment.WithStop(base.Start)
+	if t.Segment.Len() == 0 {
+		t.Parent().RemoveChild(t.Parent(), t)
+	}
+	return base
+}
+
+// isScriptPair reports whether a and b are a subscript and a superscript, in either order.
+func isScriptPair(a, b ast.Node) bool {
+	switch a.(type) {
+	case *Node:
+		_, ok := b.(*SuperscriptNode)
+		return ok
+	case *SuperscriptNode:
+		_, ok := b.(*Node)
+		return ok
+	}
+	return false
+}
+
+// StackStyle selects how stacked subscript/superscript pairs are rendered to HTML.
+type StackStyle int
+
+const (
+	// StackNone leaves subscripts and superscripts side by side. This is the default.
+	StackNone StackStyle = iota
+
+	// StackHTML renders a pair as <span class="subsup"><sub>i</sub><sup>2</sup></span>; a stylesheet stacks them.
+	StackHTML
+
+	// StackMathML renders a pair as <math><msubsup><mi>x</mi><mi>i</mi><mn>2</mn></msubsup></math>. The letter or
+	// digit before the pair becomes the base of the msubsup element; a pair after anything else (whitespace, the
+	// start of a line, punctuation or another inline element) gets an empty <mrow></mrow> base.
+	StackMathML
+)
+
+// SubSuperscriptHTMLRenderer renders SubSuperscript nodes to HTML or MathML.
+type SubSuperscriptHTMLRenderer struct {
+	html.Config
+	attributeFilter util.BytesFilter
+	style           StackStyle
+}
+
+// NewSubSuperscriptHTMLRenderer returns a new SubSuperscriptHTMLRenderer with the given options.
+//
+// The output is selected with WithStackedScripts; StackNone renders the same as StackHTML.
+func NewSubSuperscriptHTMLRenderer(opts ...SubscriptOption) renderer.NodeRenderer {
+	return newSubSuperscriptHTMLRenderer(newConfig(opts))
+}
+
+// newSubSuperscriptRenderer returns the renderer for SubSuperscript nodes selected by config. Only the HTML and
+// LaTeX formats get this far, because superscripts cannot be rendered with the others.
+func newSubSuperscriptRenderer(config Config) renderer.NodeRenderer {
+	if config.OutputFormat == OutputLaTeX {
+		return NewSubSuperscriptLaTeXRenderer()
+	}
+	return newSubSuperscriptHTMLRenderer(config)
+}
+
+func newSubSuperscriptHTMLRenderer(config Config) *SubSuperscriptHTMLRenderer {
+	r := &SubSuperscriptHTMLRenderer{
+		style: config.Stacked,
+	}
+	r.Config, r.attributeFilter = newHTMLConfig(config)
+	return r
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *SubSuperscriptHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindSubSuperscript, r.renderSubSuperscript)
+}
+
+func (r *SubSuperscriptHTMLRenderer) renderSubSuperscript(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if r.style == StackMathML {
+		if entering {
+			r.renderMathML(w, source, n.(*SubSuperscriptNode))
+		}
+		return ast.WalkSkipChildren, nil
+	}
+	if entering {
+		_, _ = w.Write(util.EscapeHTML(n.(*SubSuperscriptNode).Base.Value(source)))
+		_, _ = w.WriteString(`<span class="subsup"`)
+		if n.Attributes() != nil {
+			html.RenderAttributes(w, n, r.attributeFilter)
+		}
+		_ = w.WriteByte('>')
+	} else {
+		_, _ = w.WriteString("</span>")
+	}
+	return ast.WalkContinue, nil
+}
+
+// renderMathML writes the pair as an msubsup element on its base, or on an empty mrow if it has none.
+func (r *SubSuperscriptHTMLRenderer) renderMathML(w util.BufWriter, source []byte, n *SubSuperscriptNode) {
+	_, _ = w.WriteString("<math")
+	if n.Attributes() != nil {
+		html.RenderAttributes(w, n, r.attributeFilter)
+	}
+	_, _ = w.WriteString("><msubsup>")
+	if n.Base.Len() > 0 {
+		writeMathMLTokens(w, n.Base.Value(source))
+	} else {
+		_, _ = w.WriteString("<mrow></mrow>")
+	}
+	writeMathMLTokens(w, appendPlainText(nil, n.Subscript(), source))
+	writeMathMLTokens(w, appendPlainText(nil, n.Superscript(), source))
+	_, _ = w.WriteString("</msubsup></math>")
+}
+
+// writeMathMLTokens writes s as a single MathML token, or as an mrow of tokens: numbers become mn,
+// runs of letters mi and everything else mo.
+func writeMathMLTokens(w util.BufWriter, s []byte) {
+	tokens := mathMLTokens(s)
+	if len(tokens) != 1 {
+		_, _ = w.WriteString("<mrow>")
+	}
+	for _, t := range tokens {
+		_, _ = w.WriteString("<" + t.element + ">")
+		_, _ = w.Write(util.EscapeHTML(t.value))
+		_, _ = w.WriteString("</" + t.element + ">")
+	}
+	if len(tokens) != 1 {
+		_, _ = w.WriteString("</mrow>")
+	}
+}
+
+type mathMLToken struct {
+	element string
+	value   []byte
+}
+
+func mathMLTokens(s []byte) []mathMLToken {
+	var tokens []mathMLToken
+	for i := 0; i < len(s); {
+		j := i + 1
+		element := "mo"
+		switch {
+		case isDigit(s[i]):
+			element = "mn"
+			for j < len(s) && (isDigit(s[j]) || s[j] == '.') {
+				j++
+			}
+		case util.IsAlphaNumeric(s[i]) || s[i] >= 0x80:
+			element = "mi"
+			for j < len(s) && (util.IsAlphaNumeric(s[j]) && !isDigit(s[j]) || s[j] >= 0x80) {
+				j++
+			}
+		}
+		tokens = append(tokens, mathMLToken{element: element, value: s[i:j]})
+		i = j
+	}
+	return tokens
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// appendNodeText appends the text below n to buf.
+func appendNodeText(buf []byte, n ast.Node, source []byte) []byte {
+	if n == nil {
+		return buf
+	}
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch t := c.(type) {
+		case *ast.Text:
+			buf = append(buf, t.Segment.Value(source)...)
+		case *ast.String:
+			buf = append(buf, t.Value...)
+		default:
+			buf = appendNodeText(buf, c, source)
+		}
+	}
+	return buf
+}
@@ -0,0 +1,178 @@
+package subscript
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/testutil"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+func TestStackedScripts(t *testing.T) {
+	mdHTML := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			NewScripts(WithStackedScripts(StackHTML)),
+		),
+	)
+
+	testCases := []TestCase{
+		{
+			desc: "Stacked: subscript then superscript",
+			md:   `x~i~^2^`,
+			html: `<p>x<span class="subsup"><sub>i</sub><sup>2</sup></span></p>`,
+		},
+		{
+			desc: "Stacked: superscript then subscript",
+			md:   `x^2^~i~`,
+			html: `<p>x<span class="subsup"><sub>i</sub><sup>2</sup></span></p>`,
+		},
+		{
+			desc: "Stacked: a script is part of one pair only",
+			md:   `x~i~^2^~j~`,
+			html: `<p>x<span class="subsup"><sub>i</sub><sup>2</sup></span><sub>j</sub></p>`,
+		},
+		{
+			desc: "Stacked: scripts separated by text are not stacked",
+			md:   `x~i~ ^2^ and SO~4~`,
+			html: `<p>x<sub>i</sub> <sup>2</sup> and SO<sub>4</sub></p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(mdHTML, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+
+	mdMathML := goldmark.New(
+		goldmark.WithExtensions(
+			NewScripts(WithStackedScripts(StackMathML)),
+		),
+	)
+
+	mathMLCases := []TestCase{
+		{
+			desc: "Stacked MathML: tensor index",
+			md:   `x~i~^2^`,
+			html: `<p><math><msubsup><mi>x</mi><mi>i</mi><mn>2</mn></msubsup></math></p>`,
+		},
+		{
+			desc: "Stacked MathML: ion",
+			md:   `T~max~^2-^`,
+			html: `<p><math><msubsup><mi>T</mi><mi>max</mi><mrow><mn>2</mn><mo>-</mo></mrow></msubsup></math></p>`,
+		},
+		{
+			desc: "Stacked MathML: escaping",
+			md:   `x~i<j~^a&b^`,
+			html: `<p><math><msubsup><mi>x</mi><mrow><mi>i</mi><mo>&lt;</mo><mi>j</mi></mrow>` +
+				`<mrow><mi>a</mi><mo>&amp;</mo><mi>b</mi></mrow></msubsup></math></p>`,
+		},
+		{
+			desc: "Stacked MathML: only the last character is the base",
+			md:   `The 10~i~^2^ term`,
+			html: `<p>The 1<math><msubsup><mn>0</mn><mi>i</mi><mn>2</mn></msubsup></math> term</p>`,
+		},
+		{
+			desc: "Stacked MathML: no base after punctuation",
+			md:   `(~i~^2^)`,
+			html: `<p>(<math><msubsup><mrow></mrow><mi>i</mi><mn>2</mn></msubsup></math>)</p>`,
+		},
+	}
+
+	for _, tc := range mathMLCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(mdMathML, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+}
+
+func TestStackedScriptsMathMLBase(t *testing.T) {
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			NewScripts(WithStackedScripts(StackMathML), WithFormulaMetadata()),
+		),
+	)
+
+	// The formula transformers run before the base is moved out of the text
+	testutil.DoTestCase(md, testutil.MarkdownTestCase{
+		Description: "Stacked MathML: base of an ion",
+		Markdown:    `SO~4~^2-^`,
+		Expected: `<p><span class="chem" title="O4S^2-, 96.06 g/mol" data-formula="O4S^2-" data-molar-mass="96.06">` +
+			`S<math><msubsup><mi>O</mi><mn>4</mn><mrow><mn>2</mn><mo>-</mo></mrow></msubsup></math></span></p>`,
+	}, t)
+
+	source := []byte(`x~i~^2^ and SO~4~^2-^`)
+	if got := string(ExtractText(md.Parser().Parse(text.NewReader(source)), source, TextInline)); got != "xi2 and SO42-" {
+		t.Errorf("ExtractText = %q, want %q", got, "xi2 and SO42-")
+	}
+}
+
+func TestStackedScriptsNode(t *testing.T) {
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			NewScripts(WithStackedScripts(StackHTML)),
+		),
+	)
+	source := []byte(`x^2^~i~`)
+	doc := md.Parser().Parse(text.NewReader(source))
+	stacked, ok := doc.FirstChild().LastChild().(*SubSuperscriptNode)
+	if !ok {
+		t.Fatalf("expected a SubSuperscript node, got %T", doc.FirstChild().LastChild())
+	}
+	if !stacked.SuperscriptFirst {
+		t.Error("expected SuperscriptFirst to be set")
+	}
+	if got := string(appendNodeText(nil, stacked.Subscript(), source)); got != "i" {
+		t.Errorf("Subscript() = %q, want %q", got, "i")
+	}
+	if got := string(appendNodeText(nil, stacked.Superscript(), source)); got != "2" {
+		t.Errorf("Superscript() = %q, want %q", got, "2")
+	}
+}
+
+func TestStackedScriptsLaTeX(t *testing.T) {
+	latex := renderer.NewRenderer(renderer.WithNodeRenderers(
+		util.Prioritized(NewSubSuperscriptLaTeXRenderer(), 100),
+	))
+
+	// Nodes without a LaTeX renderer are skipped, so only the stacked pair is written
+	testCases := []struct {
+		style StackStyle
+		md    string
+		latex string
+	}{
+		{StackHTML, `x~i~^2^`, `$_{i}^{2}$`},
+		{StackHTML, `x^2^~i~`, `$_{i}^{2}$`},
+		{StackHTML, `x~a_b~^50%^`, `$_{a\_b}^{50\%}$`},
+		{StackMathML, `x~i~^2^`, `$x_{i}^{2}$`},
+	}
+
+	for _, tc := range testCases {
+		md := goldmark.New(
+			goldmark.WithExtensions(
+				NewScripts(WithStackedScripts(tc.style)),
+			),
+		)
+		var buf bytes.Buffer
+		source := []byte(tc.md)
+		if err := latex.Render(&buf, source, md.Parser().Parse(text.NewReader(source))); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != tc.latex {
+			t.Errorf("%s: got %q, want %q", tc.md, got, tc.latex)
+		}
+	}
+}
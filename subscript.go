@@ -14,14 +14,42 @@
 // The extension follows these parsing rules:
 //   - Subscripts must not start at the beginning of a line or after whitespace
 //   - Content between tildes cannot contain spaces or additional tildes
+//     (spaces escaped with a backslash, as in "T~max\ value~", are allowed)
 //   - Empty subscripts (~~ with no content) are not parsed as subscripts
+//
+// The extension can be configured with SubscriptOption values:
+//
+//	md := goldmark.New(
+//		goldmark.WithExtensions(
+//			subscript.NewSubscript(
+//				subscript.WithParserPriority(90),
+//				subscript.WithHTMLOptions(html.WithXHTML()),
+//				subscript.WithAllowAfterWhitespace(),
+//			),
+//		),
+//	)
+//
+// Dialect presets (DialectPandoc, DialectMarkdownItSub, DialectGFMSafe, DialectMultiMarkdown and DialectObsidian)
+// are SubscriptOption values that bundle the parsing rules of other markdown tools:
+//
+//	subscript.NewSubscript(subscript.DialectPandoc)
+//
+// A preset sets every parsing rule (AllowLineStart, AllowAfterWhitespace, EscapedSpaces, InlineMarkdown,
+// WhitespaceFlanking and TildeMode) and leaves priorities and rendering settings alone. Options given after
+// a preset override it.
+//
+// The package also provides a superscript extension (x^2^), and Scripts enables both.
 package subscript
 
 import (
+	"bytes"
+	"sync"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer"
 	"github.com/yuin/goldmark/renderer/html"
@@ -53,33 +81,67 @@ func NewSubscriptNode() *Node {
 }
 
 // subscriptParser implements parser.InlineParser for subscript syntax.
+//
+// A subscriptParser is never modified after it has been created, apart from detecting
+// the strikethrough parser once, so it can be shared by concurrent Convert calls.
 type subscriptParser struct {
-}
+	Config
+
+	// parserConfig is the configuration of the Goldmark parser the extension was added to, if any.
+	parserConfig *parser.Config
 
-var defaultSubscriptParser = &subscriptParser{}
+	detectOnce       sync.Once
+	hasStrikethrough bool
+}
 
 // NewSubscriptParser returns a new InlineParser that parses subscript expressions.
-func NewSubscriptParser() parser.InlineParser {
-	return defaultSubscriptParser
+func NewSubscriptParser(opts ...SubscriptOption) parser.InlineParser {
+	return newSubscriptParser(newConfig(opts))
 }
 
+func newSubscriptParser(config Config) *subscriptParser {
+	return &subscriptParser{
+		Config: config,
+	}
+}
+
+// tildeTrigger is returned by Trigger, so that Trigger does not allocate.
+var tildeTrigger = []byte{'~'}
+
 // Trigger implements parser.InlineParser.Trigger.
 func (s *subscriptParser) Trigger() []byte {
-	return []byte{'~'}
+	return tildeTrigger
 }
 
 // Parse implements parser.InlineParser.Parse.
 func (s *subscriptParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	if s.WhitespaceFlanking || s.TildeMode.ownsStrikethrough() {
+		return s.parseDelimiter(block, pc)
+	}
+
 	before := block.PrecendingCharacter()
 	line, segment := block.PeekLine()
 
+	// In inline markdown mode, close a subscript that was opened earlier on this line
+	if s.InlineMarkdown {
+		if state := s.state(block, pc); state.closer == segment.Start {
+			state.closer = -1
+			return s.pushDelimiter(block, segment, pc, false, true)
+		}
+	}
+
+	// Runs of three or more tildes are never subscripts or strikethroughs
+	if n := tildeRun(line); n > 2 {
+		return literalRun(block, segment, n)
+	}
+
 	// Check if we have at least one character after the tilde
 	if len(line) < 2 {
 		return nil
 	}
 
-	// If preceded by whitespace or is first character of line, not a subscript
-	if unicode.IsSpace(before) || before == -1 {
+	// If preceded by whitespace or is first character of line, not a subscript (unless configured otherwise)
+	if !s.canOpenAfter(before) {
 		return nil
 	}
 
@@ -88,6 +150,11 @@ func (s *subscriptParser) Parse(parent ast.Node, block text.Reader, pc parser.Co
 		return nil
 	}
 
+	// The same goes for the last tilde of a longer run, unless the tilde before it closed a subscript ("H~2~~O~")
+	if before == '~' && !closesSubscript(parent.LastChild(), segment.Start-1) {
+		return nil
+	}
+
 	// Find the content between tildes
 	start := 1 // Skip the opening tilde
 	end := -1
@@ -105,28 +172,18 @@ func (s *subscriptParser) Parse(parent ast.Node, block text.Reader, pc parser.Co
 		return nil
 	}
 
-	// Check if there's any content between tildes
-	if end <= start {
-		return nil
-	}
-
+	// Check the content between tildes: it must not be empty or contain (unescaped) whitespace
 	content := line[start:end]
-
-	// Check if content has any whitespace (not allowed in subscript)
-	for _, b := range content {
-		if unicode.IsSpace(rune(b)) {
-			return nil
-		}
-	}
-
-	// Check first character requirements: allow any non-whitespace character except tilde
-	firstChar := rune(content[0])
-	if firstChar == '~' {
+	if !s.isValidContent(content) {
 		return nil
 	}
 
-	// All subsequent characters are allowed except tilde (handled by finding closing tilde above)
-	// No additional character validation needed since whitespace is already checked above
+	// In inline markdown mode, leave the content to the other inline parsers and let
+	// the delimiter processor wrap it in a subscript node once the closing tilde is reached
+	if s.InlineMarkdown {
+		s.state(block, pc).closer = segment.Start + end
+		return s.pushDelimiter(block, segment, pc, true, false)
+	}
 
 	// Create the subscript node
 	node := NewSubscriptNode()
@@ -134,10 +191,10 @@ func (s *subscriptParser) Parse(parent ast.Node, block text.Reader, pc parser.Co
 	// Advance past the opening tilde
 	block.Advance(1)
 
-	// Parse the content inside - create a text segment for the content
+	// Parse the content inside - create text segments for the content
 	tempSegment := segment.WithStart(segment.Start + start)
 	contentSegment := tempSegment.WithStop(segment.Start + end)
-	node.AppendChild(node, ast.NewTextSegment(contentSegment))
+	s.appendContent(node, contentSegment, content)
 
 	// Advance past the content and closing tilde
 	block.Advance(end)
@@ -145,24 +202,177 @@ func (s *subscriptParser) Parse(parent ast.Node, block text.Reader, pc parser.Co
 	return node
 }
 
-// CloseBlock implements parser.InlineParser.CloseBlock.
-func (s *subscriptParser) CloseBlock(parent ast.Node, pc parser.Context) {
-	// nothing to do
+// canOpenAfter reports whether a subscript can open after the given preceding character.
+// Subscripts cannot open at the beginning of a line or after whitespace unless configured otherwise,
+// or unless there is no strikethrough parser that could use the tilde instead.
+func (s *subscriptParser) canOpenAfter(before rune) bool {
+	switch {
+	case before == -1 || before == '\n' || before == '\r':
+		return s.AllowLineStart || !s.strikethrough()
+	case s.isSpace(before):
+		return s.AllowAfterWhitespace || !s.strikethrough()
+	}
+	return true
+}
+
+// strikethrough reports whether tildes that do not form a subscript can become a strikethrough.
+func (s *subscriptParser) strikethrough() bool {
+	switch {
+	case s.TildeMode.ownsStrikethrough():
+		return true
+	case s.Strikethrough != StrikethroughAuto:
+		return s.Strikethrough == StrikethroughPresent
+	}
+	// Inline parsers are only final once the Goldmark parser has been initialized by its first Parse
+	s.detectOnce.Do(func() {
+		s.hasStrikethrough = s.parserConfig == nil || hasTildeParser(s.parserConfig.InlineParsers, s)
+	})
+	return s.hasStrikethrough
+}
+
+// strikesInvalidPairs reports whether a pair of single tildes that cannot be a subscript becomes a strikethrough.
+func (s *subscriptParser) strikesInvalidPairs() bool {
+	return s.TildeMode != TildeModePandoc && s.strikethrough()
+}
+
+// hasTildeParser reports whether an inline parser other than self is triggered by '~'.
+func hasTildeParser(parsers util.PrioritizedSlice, self parser.InlineParser) bool {
+	for _, v := range parsers {
+		p, ok := v.Value.(parser.InlineParser)
+		if !ok || p == self {
+			continue
+		}
+		if bytes.IndexByte(p.Trigger(), '~') >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// parserConfigOption is a parser.Option that hands the Goldmark parser configuration to a subscriptParser.
+type parserConfigOption struct {
+	parser *subscriptParser
+}
+
+// SetParserOption implements parser.Option.
+func (o parserConfigOption) SetParserOption(c *parser.Config) {
+	o.parser.parserConfig = c
+}
+
+// tildeRun returns the number of tildes at the start of line.
+func tildeRun(line []byte) int {
+	n := 0
+	for n < len(line) && line[n] == '~' {
+		n++
+	}
+	return n
+}
+
+// literalRun consumes a run of n tildes as a single text node.
+//
+// No inline parser makes use of a run of three or more tildes. Consuming it at once keeps
+// parsing linear, where trying every tilde of the run again would be quadratic.
+func literalRun(block text.Reader, segment text.Segment, n int) ast.Node {
+	block.Advance(n)
+	return ast.NewTextSegment(segment.WithStop(segment.Start + n))
+}
+
+// closesSubscript reports whether n, the last inline parsed so far, ends with the closing tilde
+// of a subscript at pos.
+func closesSubscript(n ast.Node, pos int) bool {
+	switch n := n.(type) {
+	case *Node:
+		t, ok := n.LastChild().(*ast.Text)
+		return ok && t.Segment.Stop == pos
+	case *parser.Delimiter:
+		_, ok := n.Processor.(*tildeDelimiterProcessor)
+		return ok && n.Segment.Start == pos && n.CanClose && !n.CanOpen
+	}
+	return false
+}
+
+// isValidContent reports whether content can be the content of a subscript.
+// Content must not be empty and must not contain whitespace (unless escaped) or tildes
+// (tildes are allowed in inline markdown mode, where they belong to nested strikethroughs).
+func (s *subscriptParser) isValidContent(content []byte) bool {
+	return len(content) > 0 && s.invalidIndex(content) < 0
+}
+
+// invalidIndex returns the index of the first character that is not allowed in the content
+// of a subscript, or -1.
+func (s *subscriptParser) invalidIndex(content []byte) int {
+	for i := 0; i < len(content); {
+		r, n := utf8.DecodeRune(content[i:])
+		if s.isSpace(r) && !(s.EscapedSpaces && r == ' ' && isEscapedSpace(content, i)) {
+			return i
+		}
+		if r == '~' && !s.InlineMarkdown {
+			return i
+		}
+		i += n
+	}
+	return -1
+}
+
+// isSpace reports whether r counts as whitespace under the configured space policy.
+func (c *Config) isSpace(r rune) bool {
+	if c.IsSpace == nil {
+		return unicode.IsSpace(r)
+	}
+	return c.IsSpace(r)
+}
+
+// appendContent appends the raw content of a subscript or superscript to node as text segments.
+// Escaped spaces split the content into several segments, dropping each backslash. Other backslash escapes,
+// such as the escaped backslash in "a\\\ b", stay in the segments like in any text node: renderers resolve them
+// (see appendPlainText), so that content renders as "a\ b".
+func (c *Config) appendContent(node ast.Node, segment text.Segment, content []byte) {
+	start := segment.Start
+	for i := 0; c.EscapedSpaces && i < len(content); i++ {
+		if content[i] == ' ' && isEscapedSpace(content, i) {
+			escaped := start + i
+			node.AppendChild(node, ast.NewTextSegment(segment.WithStop(escaped-1)))
+			segment = segment.WithStart(escaped)
+		}
+	}
+	node.AppendChild(node, ast.NewTextSegment(segment))
+}
+
+// isEscapedSpace reports whether the space at content[i] is preceded by an odd number of backslashes.
+func isEscapedSpace(content []byte, i int) bool {
+	n := 0
+	for j := i - 1; j >= 0 && content[j] == '\\'; j-- {
+		n++
+	}
+	return n%2 == 1
 }
 
 // SubscriptHTMLRenderer renders Subscript nodes to HTML <sub> elements.
+//
+// Each renderer owns a private copy of its attribute filter, so renderers of
+// different goldmark instances never share mutable state.
 type SubscriptHTMLRenderer struct {
 	html.Config
+	attributeFilter util.BytesFilter
 }
 
 // NewSubscriptHTMLRenderer returns a new SubscriptHTMLRenderer with the given options.
 func NewSubscriptHTMLRenderer(opts ...html.Option) renderer.NodeRenderer {
-	r := &SubscriptHTMLRenderer{
-		Config: html.NewConfig(),
-	}
-	for _, opt := range opts {
-		opt.SetHTMLOption(&r.Config)
-	}
+	config := NewConfig()
+	config.HTMLOptions = opts
+	return newSubscriptHTMLRenderer(config)
+}
+
+// NewSubscriptHTMLRendererWithConfig returns a new SubscriptHTMLRenderer with the settings of config,
+// such as its attribute filter and HTML options. Start from NewConfig and apply SubscriptOption values to it
+// to render with the same settings as the extension.
+func NewSubscriptHTMLRendererWithConfig(config Config) renderer.NodeRenderer {
+	return newSubscriptHTMLRenderer(config)
+}
+
+func newSubscriptHTMLRenderer(config Config) *SubscriptHTMLRenderer {
+	r := &SubscriptHTMLRenderer{}
+	r.Config, r.attributeFilter = newHTMLConfig(config)
 	return r
 }
 
@@ -171,16 +381,12 @@ func (r *SubscriptHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegis
 	reg.Register(KindSubscript, r.renderSubscript)
 }
 
-// SubscriptAttributeFilter defines attribute names which subscript elements can have.
-// Uses the global HTML attribute filter for consistency with other HTML elements.
-var SubscriptAttributeFilter = html.GlobalAttributeFilter
-
 func (r *SubscriptHTMLRenderer) renderSubscript(
 	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
 	if entering {
 		if n.Attributes() != nil {
 			_, _ = w.WriteString("<sub")
-			html.RenderAttributes(w, n, SubscriptAttributeFilter)
+			html.RenderAttributes(w, n, r.attributeFilter)
 			_ = w.WriteByte('>')
 		} else {
 			_, _ = w.WriteString("<sub>")
@@ -192,29 +398,120 @@ func (r *SubscriptHTMLRenderer) renderSubscript(
 }
 
 // subscript implements goldmark.Extender for the subscript extension.
-type subscript struct{}
-
-// SubscriptOption configures the subscript extension.
-type SubscriptOption func(*subscript)
+type subscript struct {
+	config Config
+}
 
 // Subscript is a pre-configured subscript extension instance.
 var Subscript = NewSubscript()
 
 // NewSubscript creates a new subscript extension with the given options.
 func NewSubscript(opts ...SubscriptOption) *subscript {
-	s := &subscript{}
-	for _, opt := range opts {
-		opt(s)
+	return &subscript{
+		config: newConfig(opts),
 	}
-	return s
 }
 
 // Extend implements goldmark.Extender by adding subscript parsing and rendering to the markdown processor.
 func (s *subscript) Extend(m goldmark.Markdown) {
-	m.Parser().AddOptions(parser.WithInlineParsers(
-		util.Prioritized(NewSubscriptParser(), 100),
-	))
+	extendSubscript(m, s.config)
+}
+
+// extendSubscript adds the subscript parser and renderers configured by config to m.
+func extendSubscript(m goldmark.Markdown, config Config) {
+	if config.OutputFormat != OutputHTML && (config.Chemistry || config.ChemBlocks || config.FormulaMetadata) {
+		panic("subscript: chemical formulas cannot be rendered with " + config.OutputFormat.String())
+	}
+	p := newSubscriptParser(config)
+	m.Parser().AddOptions(
+		parser.WithInlineParsers(util.Prioritized(p, config.ParserPriority)),
+		parserConfigOption{parser: p},
+	)
 	m.Renderer().AddOptions(renderer.WithNodeRenderers(
-		util.Prioritized(NewSubscriptHTMLRenderer(), 100),
+		util.Prioritized(newSubscriptRenderer(config), config.RendererPriority),
 	))
+	if config.TildeMode.ownsStrikethrough() && config.OutputFormat == OutputHTML {
+		m.Renderer().AddOptions(renderer.WithNodeRenderers(
+			util.Prioritized(extension.NewStrikethroughHTMLRenderer(config.HTMLOptions...), config.RendererPriority),
+		))
+	}
+	if config.UnicodeInput {
+		// runs before the other transformers, so they see the subscripts
+		m.Parser().AddOptions(parser.WithASTTransformers(
+			util.Prioritized(NewUnicodeSubscriptTransformer(), config.ParserPriority-1),
+		))
+	}
+	if config.HTMLInput {
+		m.Parser().AddOptions(parser.WithASTTransformers(
+			util.Prioritized(NewRawHTMLSubscriptTransformer(), config.ParserPriority-1),
+		))
+	}
+	if config.Chemistry {
+		m.Parser().AddOptions(parser.WithASTTransformers(
+			util.Prioritized(NewChemistryTransformer(), config.ParserPriority),
+		))
+	}
+	if config.ChemBlocks {
+		m.Parser().AddOptions(parser.WithASTTransformers(
+			util.Prioritized(NewChemBlockTransformer(), config.ParserPriority),
+		))
+		m.Renderer().AddOptions(renderer.WithNodeRenderers(
+			util.Prioritized(newChemBlockHTMLRenderer(config), config.RendererPriority),
+		))
+	}
+	if config.Chemistry || config.ChemBlocks {
+		m.Renderer().AddOptions(renderer.WithNodeRenderers(
+			util.Prioritized(newSuperscriptHTMLRenderer(config), config.RendererPriority),
+		))
+	}
+	if config.Chemistry || config.ChemBlocks || config.FormulaMetadata {
+		m.Renderer().AddOptions(renderer.WithNodeRenderers(
+			util.Prioritized(newFormulaHTMLRenderer(config), config.RendererPriority),
+		))
+	}
+	// the formula transformers run after the other transformers, so they see the final tree
+	if config.ValidateFormulas {
+		m.Parser().AddOptions(parser.WithASTTransformers(
+			util.Prioritized(NewFormulaValidator(), config.ParserPriority+1000),
+		))
+	}
+	if config.FormulaMetadata {
+		m.Parser().AddOptions(parser.WithASTTransformers(
+			util.Prioritized(NewFormulaMetadataTransformer(), config.ParserPriority+1000),
+		))
+	}
+}
+
+// newSubscriptRenderer returns the renderer for Subscript nodes selected by config.
+func newSubscriptRenderer(config Config) renderer.NodeRenderer {
+	switch {
+	case config.OutputFormat == OutputLaTeX:
+		return newSubscriptLaTeXRenderer(config)
+	case config.OutputFormat == OutputTypst:
+		return NewSubscriptTypstRenderer()
+	case config.OutputFormat == OutputAsciiDoc:
+		return NewSubscriptAsciiDocRenderer()
+	case config.OutputFormat == OutputJira:
+		return NewSubscriptJiraRenderer()
+	case config.OutputFormat == OutputRST:
+		return NewSubscriptRSTRenderer()
+	case config.OutputFormat == OutputOrg:
+		return NewSubscriptOrgRenderer()
+	case config.OutputFormat == OutputBBCode:
+		return NewSubscriptBBCodeRenderer()
+	case config.UnicodeSubscripts:
+		return newSubscriptUnicodeRenderer(config)
+	}
+	return newSubscriptHTMLRenderer(config)
+}
+
+// Tilde is a pre-configured extension that parses both subscripts and strikethroughs (TildeModeGFM).
+var Tilde = NewTilde()
+
+// NewTilde creates an extension that parses both "~subscript~" and "~~strikethrough~~".
+//
+// It replaces Goldmark's extension.Strikethrough, which should not be added as well: one tilde is a subscript
+// and two tildes are a strikethrough. NewTilde uses TildeModeGFM unless WithTildeMode is given.
+func NewTilde(opts ...SubscriptOption) *subscript {
+	return NewSubscript(append([]SubscriptOption{WithTildeMode(TildeModeGFM)}, opts...)...)
 }
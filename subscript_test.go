@@ -1,12 +1,21 @@
 package subscript
 
 import (
+	"bytes"
+	"sync"
 	"testing"
+	"unicode"
 
 	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
 	"github.com/yuin/goldmark/renderer/html"
 	"github.com/yuin/goldmark/testutil"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
 )
 
 type TestCase struct {
@@ -477,3 +486,953 @@ func TestSubscriptOther(t *testing.T) {
 	}
 
 }
+
+func TestSubscriptTildeRunEnds(t *testing.T) {
+	// The last tilde of a run of tildes only opens a subscript when the tilde before it closed one
+	testCases := []struct {
+		desc       string
+		extensions []goldmark.Extender
+		md         string
+		html       string
+	}{
+		{
+			desc:       "Tilde run ends: adjacent subscripts",
+			extensions: []goldmark.Extender{extension.GFM, NewSubscript()},
+			md:         `H~2~~O~ and x~a~~b~`,
+			html:       `<p>H<sub>2</sub><sub>O</sub> and x<sub>a</sub><sub>b</sub></p>`,
+		},
+		{
+			desc:       "Tilde run ends: three tildes",
+			extensions: []goldmark.Extender{extension.GFM, NewSubscript()},
+			md:         `This will ~~~not~~~ strike.`,
+			html:       `<p>This will ~~~not~~~ strike.</p>`,
+		},
+		{
+			desc:       "Tilde run ends: two tildes without a strikethrough parser",
+			extensions: []goldmark.Extender{NewSubscript(WithAllowLineStart(), WithAllowAfterWhitespace())},
+			md:         `~~text~~ and a~~b~`,
+			html:       `<p>~~text~~ and a~~b~</p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(goldmark.New(goldmark.WithExtensions(tc.extensions...)), testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+}
+
+func TestSubscriptOptions(t *testing.T) {
+	testCases := []struct {
+		desc string
+		opts []SubscriptOption
+		md   string
+		html string
+	}{
+		{
+			desc: "Options: default options",
+			opts: nil,
+			md:   `~2~O and H ~2~ O`,
+			html: `<p><del>2</del>O and H <del>2</del> O</p>`,
+		},
+		{
+			desc: "Options: allow subscript at beginning of line",
+			opts: []SubscriptOption{WithAllowLineStart()},
+			md:   `~2~O and H ~2~ O`,
+			html: `<p><sub>2</sub>O and H <del>2</del> O</p>`,
+		},
+		{
+			desc: "Options: allow subscript after whitespace",
+			opts: []SubscriptOption{WithAllowAfterWhitespace()},
+			md:   `~2~O and H ~2~ O`,
+			html: `<p><del>2</del>O and H <sub>2</sub> O</p>`,
+		},
+		{
+			desc: "Options: parser priority lower than strikethrough",
+			opts: []SubscriptOption{WithParserPriority(600)},
+			md:   `H~2~O`,
+			html: `<p>H<del>2</del>O</p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			mdTest := goldmark.New(
+				goldmark.WithExtensions(
+					extension.GFM,
+					NewSubscript(tc.opts...),
+				),
+			)
+			testutil.DoTestCase(mdTest, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+}
+
+// subscriptAttributeTransformer adds attributes to every Subscript node so attribute rendering can be tested.
+type subscriptAttributeTransformer struct{}
+
+func (subscriptAttributeTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering && n.Kind() == KindSubscript {
+			n.SetAttributeString("class", []byte("idx"))
+			n.SetAttributeString("onclick", []byte("alert(1)"))
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func TestSubscriptRendererOptions(t *testing.T) {
+	testCases := []struct {
+		desc string
+		opts []SubscriptOption
+		md   string
+		html string
+	}{
+		{
+			desc: "Renderer options: default attribute filter",
+			opts: nil,
+			md:   `H~2~O`,
+			html: `<p>H<sub class="idx">2</sub>O</p>`,
+		},
+		{
+			desc: "Renderer options: custom attribute filter",
+			opts: []SubscriptOption{WithAttributeFilter(util.NewBytesFilter([]byte("onclick")))},
+			md:   `H~2~O`,
+			html: `<p>H<sub onclick="alert(1)">2</sub>O</p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			mdTest := goldmark.New(
+				goldmark.WithExtensions(
+					NewSubscript(tc.opts...),
+				),
+				goldmark.WithParserOptions(
+					parser.WithASTTransformers(util.Prioritized(subscriptAttributeTransformer{}, 100)),
+				),
+			)
+			testutil.DoTestCase(mdTest, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+}
+
+func TestSubscriptHTMLRendererConstructors(t *testing.T) {
+	config := NewConfig()
+	WithAttributeFilter(util.NewBytesFilter([]byte("onclick")))(&config)
+
+	testCases := []struct {
+		desc     string
+		renderer renderer.NodeRenderer
+		html     string
+	}{
+		{
+			desc:     "Renderer constructors: HTML options",
+			renderer: NewSubscriptHTMLRenderer(html.WithXHTML()),
+			html:     `<p>H<sub class="idx">2</sub>O</p>`,
+		},
+		{
+			desc:     "Renderer constructors: config",
+			renderer: NewSubscriptHTMLRendererWithConfig(config),
+			html:     `<p>H<sub onclick="alert(1)">2</sub>O</p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			mdTest := goldmark.New(
+				goldmark.WithParserOptions(
+					parser.WithInlineParsers(util.Prioritized(NewSubscriptParser(), 100)),
+					parser.WithASTTransformers(util.Prioritized(subscriptAttributeTransformer{}, 100)),
+				),
+				goldmark.WithRendererOptions(
+					renderer.WithNodeRenderers(util.Prioritized(tc.renderer, 100)),
+				),
+			)
+			testutil.DoTestCase(mdTest, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    `H~2~O`,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+}
+
+func TestOutputFormat(t *testing.T) {
+	testCases := []struct {
+		format OutputFormat
+		html   string
+	}{
+		{OutputHTML, "<p>H<sub>2</sub>O</p>\n"},
+		{OutputLaTeX, "<p>H\\textsubscript{2}O</p>\n"},
+		{OutputTypst, "<p>H#sub[2]O</p>\n"},
+		{OutputAsciiDoc, "<p>H~2~O</p>\n"},
+		{OutputJira, "<p>H{~}2{~}O</p>\n"},
+		{OutputRST, "<p>H\\ :sub:`2`\\ O</p>\n"},
+		{OutputOrg, "<p>H_{2}O</p>\n"},
+		{OutputBBCode, "<p>H[sub]2[/sub]O</p>\n"},
+	}
+
+	// The other nodes are still rendered by the HTML renderer of goldmark.New
+	for _, tc := range testCases {
+		md := goldmark.New(goldmark.WithExtensions(NewSubscript(WithOutputFormat(tc.format))))
+		var buf bytes.Buffer
+		if err := md.Convert([]byte("H~2~O"), &buf); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != tc.html {
+			t.Errorf("format %s: got %q, want %q", tc.format, got, tc.html)
+		}
+	}
+}
+
+func TestOutputFormatScripts(t *testing.T) {
+	testCases := []struct {
+		desc string
+		ext  goldmark.Extender
+		md   string
+		html string
+	}{
+		{"superscript", NewSuperscript(WithOutputFormat(OutputLaTeX)), "x^2^", "<p>x\\textsuperscript{2}</p>\n"},
+		{"superscript math", NewSuperscript(WithOutputFormat(OutputLaTeX), WithLaTeXMath(LaTeXMathIndex)),
+			"x^n+1^ and x^max^", "<p>x$^{n+1}$ and x\\textsuperscript{max}</p>\n"},
+		{"stacked html", NewScripts(WithOutputFormat(OutputLaTeX), WithStackedScripts(StackHTML)),
+			"x~i~^2^", "<p>x$_{i}^{2}$</p>\n"},
+		{"stacked mathml", NewScripts(WithOutputFormat(OutputLaTeX), WithStackedScripts(StackMathML)),
+			"x~i~^2^", "<p>$x_{i}^{2}$</p>\n"},
+		{"strikethrough", NewTilde(WithOutputFormat(OutputLaTeX)), "~~a~~ H~2~O", "<p>a H\\textsubscript{2}O</p>\n"},
+	}
+
+	// Strikethroughs are left to the renderer of the output format, which goldmark.New does not have
+	for _, tc := range testCases {
+		md := goldmark.New(goldmark.WithExtensions(tc.ext))
+		var buf bytes.Buffer
+		if err := md.Convert([]byte(tc.md), &buf); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != tc.html {
+			t.Errorf("%s: got %q, want %q", tc.desc, got, tc.html)
+		}
+	}
+}
+
+func TestOutputFormatUnsupported(t *testing.T) {
+	testCases := []struct {
+		desc string
+		ext  goldmark.Extender
+		want string
+	}{
+		{"superscript", NewSuperscript(WithOutputFormat(OutputTypst)),
+			"subscript: superscripts cannot be rendered with OutputTypst"},
+		{"scripts", NewScripts(WithOutputFormat(OutputBBCode), WithStackedScripts(StackHTML)),
+			"subscript: superscripts cannot be rendered with OutputBBCode"},
+		{"chemistry", NewSubscript(WithOutputFormat(OutputLaTeX), WithChemistry()),
+			"subscript: chemical formulas cannot be rendered with OutputLaTeX"},
+		{"chem blocks", NewScripts(WithOutputFormat(OutputRST), WithChemBlocks()),
+			"subscript: chemical formulas cannot be rendered with OutputRST"},
+		{"formula metadata", NewSubscript(WithOutputFormat(OutputOrg), WithFormulaMetadata()),
+			"subscript: chemical formulas cannot be rendered with OutputOrg"},
+	}
+
+	for _, tc := range testCases {
+		func() {
+			defer func() {
+				if got := recover(); got != tc.want {
+					t.Errorf("%s: got panic %v, want %q", tc.desc, got, tc.want)
+				}
+			}()
+			goldmark.New(goldmark.WithExtensions(tc.ext))
+		}()
+	}
+}
+
+func TestSubscriptConcurrentInstances(t *testing.T) {
+	// Instances with different settings must not influence each other, even when used concurrently.
+	strict := goldmark.New(goldmark.WithExtensions(extension.GFM, NewSubscript()))
+	relaxed := goldmark.New(goldmark.WithExtensions(extension.GFM, NewSubscript(WithAllowAfterWhitespace())))
+
+	md := []byte(`H ~2~O and H~2~O`)
+	expected := map[goldmark.Markdown]string{
+		strict:  "<p>H <del>2</del>O and H<sub>2</sub>O</p>\n",
+		relaxed: "<p>H <sub>2</sub>O and H<sub>2</sub>O</p>\n",
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		for m, want := range expected {
+			wg.Add(1)
+			go func(m goldmark.Markdown, want string) {
+				defer wg.Done()
+				var buf bytes.Buffer
+				if err := m.Convert(md, &buf); err != nil {
+					t.Error(err)
+					return
+				}
+				if buf.String() != want {
+					t.Errorf("got %q, want %q", buf.String(), want)
+				}
+			}(m, want)
+		}
+	}
+	wg.Wait()
+}
+
+func TestSubscriptParserIsNotShared(t *testing.T) {
+	if NewSubscriptParser() == NewSubscriptParser() {
+		t.Error("NewSubscriptParser returned a shared parser instance")
+	}
+}
+
+func TestSubscriptEscapedSpaces(t *testing.T) {
+	mdTest := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			NewSubscript(),
+		),
+	)
+
+	testCases := []TestCase{
+		{
+			desc: "Escaped spaces: single escaped space",
+			md:   `T~max\ value~`,
+			html: `<p>T<sub>max value</sub></p>`,
+		},
+		{
+			desc: "Escaped spaces: several escaped spaces",
+			md:   `H~a\ b\ c~O`,
+			html: `<p>H<sub>a b c</sub>O</p>`,
+		},
+		{
+			desc: "Escaped spaces: leading and trailing escaped spaces",
+			md:   `H~\ a\ ~O`,
+			html: `<p>H<sub> a </sub>O</p>`,
+		},
+		{
+			desc: "Escaped spaces: escaped backslash before a space is not an escaped space",
+			md:   `H~a\\ b~O`,
+			html: `<p>H<del>a\ b</del>O</p>`,
+		},
+		{
+			desc: "Escaped spaces: escaped backslash before an escaped space",
+			md:   `H~a\\\ b~O and H~a\\\\\ b~O`,
+			html: `<p>H<sub>a\ b</sub>O and H<sub>a\\ b</sub>O</p>`,
+		},
+		{
+			desc: "Escaped spaces: unescaped spaces still fall through to strikethrough",
+			md:   `H~a\ b c~O`,
+			html: `<p>H<del>a\ b c</del>O</p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(mdTest, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+
+	t.Run("Escaped spaces: disabled", func(t *testing.T) {
+		mdDisabled := goldmark.New(
+			goldmark.WithExtensions(
+				extension.GFM,
+				NewSubscript(WithEscapedSpaces(false)),
+			),
+		)
+		testutil.DoTestCase(mdDisabled, testutil.MarkdownTestCase{
+			Description: "Escaped spaces: disabled",
+			Markdown:    `T~max\ value~`,
+			Expected:    `<p>T<del>max\ value</del></p>`,
+		}, t)
+	})
+}
+
+func TestSubscriptInlineMarkdown(t *testing.T) {
+	mdTest := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			NewSubscript(WithInlineMarkdown()),
+		),
+	)
+
+	testCases := []TestCase{
+		{
+			desc: "Inline markdown: plain content",
+			md:   `H~2~O`,
+			html: `<p>H<sub>2</sub>O</p>`,
+		},
+		{
+			desc: "Inline markdown: emphasis",
+			md:   `x~*i*~ and x~**j**~`,
+			html: `<p>x<sub><em>i</em></sub> and x<sub><strong>j</strong></sub></p>`,
+		},
+		{
+			desc: "Inline markdown: code span",
+			md:   "x~`n`~",
+			html: `<p>x<sub><code>n</code></sub></p>`,
+		},
+		{
+			desc: "Inline markdown: link",
+			md:   `x~[1](#ref)~`,
+			html: `<p>x<sub><a href="#ref">1</a></sub></p>`,
+		},
+		{
+			desc: "Inline markdown: escaped spaces",
+			md:   `T~*max*\ value~`,
+			html: `<p>T<sub><em>max</em> value</sub></p>`,
+		},
+		{
+			desc: "Inline markdown: subscript inside emphasis",
+			md:   `*x~i~*`,
+			html: `<p><em>x<sub>i</sub></em></p>`,
+		},
+		{
+			desc: "Inline markdown: strikethrough is unaffected",
+			md:   `H~2~O is ~~not~~ ~rare~`,
+			html: `<p>H<sub>2</sub>O is <del>not</del> <del>rare</del></p>`,
+		},
+		{
+			desc: "Inline markdown: whitespace is still not allowed",
+			md:   `x~*a b*~`,
+			html: `<p>x~<em>a b</em>~</p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(mdTest, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+}
+
+func TestSubscriptWhitespaceFlanking(t *testing.T) {
+	mdTest := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			NewSubscript(WithWhitespaceFlanking()),
+		),
+	)
+
+	testCases := []TestCase{
+		{
+			desc: "Whitespace flanking: basic test",
+			md:   `H~2~O`,
+			html: `<p>H<sub>2</sub>O</p>`,
+		},
+		{
+			desc: "Whitespace flanking: glucose formula",
+			md:   `C~6~H~12~O~6~ is ~~not~~ critical for life`,
+			html: `<p>C<sub>6</sub>H<sub>12</sub>O<sub>6</sub> is <del>not</del> critical for life</p>`,
+		},
+		{
+			desc: "Whitespace flanking: pairs are resolved from left to right",
+			md:   `x~a~b~c~`,
+			html: `<p>x<sub>a</sub>b<sub>c</sub></p>`,
+		},
+		{
+			desc: "Whitespace flanking: tilde at beginning of line opens a strikethrough",
+			md:   `~H~2~O~`,
+			html: `<p><del>H</del>2<sub>O</sub></p>`,
+		},
+		{
+			desc: "Whitespace flanking: a run of two tildes is one delimiter",
+			md:   `x~a~~b~`,
+			html: `<p>x<del>a~~b</del></p>`,
+		},
+		{
+			desc: "Whitespace flanking: subscript cannot start at beginning of line",
+			md:   `~2~O`,
+			html: `<p><del>2</del>O</p>`,
+		},
+		{
+			desc: "Whitespace flanking: subscript must have non-whitespace before it",
+			md:   `H ~2~ O`,
+			html: `<p>H <del>2</del> O</p>`,
+		},
+		{
+			desc: "Whitespace flanking: spaces turn a pair into a strikethrough",
+			md:   `H~2 abc~O`,
+			html: `<p>H<del>2 abc</del>O</p>`,
+		},
+		{
+			desc: "Whitespace flanking: no trailing spaces",
+			md:   `H~2 ~O`,
+			html: `<p>H~2 ~O</p>`,
+		},
+		{
+			desc: "Whitespace flanking: no leading spaces",
+			md:   `H~ 2~O`,
+			html: `<p>H~ 2~O</p>`,
+		},
+		{
+			desc: "Whitespace flanking: nested spans resolve like emphasis",
+			md:   `C~6 ~H~ 12~O~ 6 ~`,
+			html: `<p>C<del>6 <del>H</del> 12</del>O~ 6 ~</p>`,
+		},
+		{
+			desc: "Whitespace flanking: punctuation next to the tildes",
+			md:   `x~(2)~ and Test~<tag>content</tag>~end`,
+			html: `<p>x<sub>(2)</sub> and Test<sub>&lt;tag&gt;content&lt;/tag&gt;</sub>end</p>`,
+		},
+		{
+			desc: "Whitespace flanking: content stays raw text",
+			md:   `x~*i*~`,
+			html: `<p>x<sub>*i*</sub></p>`,
+		},
+		{
+			desc: "Whitespace flanking: escaped spaces",
+			md:   `T~max\ value~`,
+			html: `<p>T<sub>max value</sub></p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(mdTest, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+
+	mdInline := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			NewSubscript(WithWhitespaceFlanking(), WithInlineMarkdown()),
+		),
+	)
+
+	inlineCases := []TestCase{
+		{
+			desc: "Whitespace flanking with inline markdown: emphasis",
+			md:   `x~*i*~`,
+			html: `<p>x<sub><em>i</em></sub></p>`,
+		},
+		{
+			desc: "Whitespace flanking with inline markdown: code span and link",
+			md:   "x~`n`~ and x~[1](#ref)~",
+			html: `<p>x<sub><code>n</code></sub> and x<sub><a href="#ref">1</a></sub></p>`,
+		},
+		{
+			desc: "Whitespace flanking with inline markdown: escaped spaces",
+			md:   `T~*max*\ value~`,
+			html: `<p>T<sub><em>max</em> value</sub></p>`,
+		},
+	}
+
+	for _, tc := range inlineCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(mdInline, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+}
+
+func TestSubscriptUnclosedLinkLabel(t *testing.T) {
+	// Pairs across an unclosed "[" must not take the link label out of the tree
+	testCases := []struct {
+		desc string
+		opts []SubscriptOption
+		html string
+	}{
+		{"whitespace flanking", []SubscriptOption{WithWhitespaceFlanking()}, `<p>x~a[~ and x~[a~</p>`},
+		{"whitespace flanking with inline markdown", []SubscriptOption{WithWhitespaceFlanking(), WithInlineMarkdown()},
+			`<p>x<sub>a[</sub> and x<sub>[a</sub></p>`},
+		{"GFM tilde mode", []SubscriptOption{WithTildeMode(TildeModeGFM)}, `<p>x<del>a[</del> and x<del>[a</del></p>`},
+		{"Pandoc tilde mode", []SubscriptOption{WithTildeMode(TildeModePandoc)}, `<p>x~a[~ and x~[a~</p>`},
+		{"markdown-it-sub dialect", []SubscriptOption{DialectMarkdownItSub}, `<p>x~a[~ and x~[a~</p>`},
+		{"Obsidian dialect", []SubscriptOption{DialectObsidian}, `<p>x~a[~ and x~[a~</p>`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			md := goldmark.New(goldmark.WithExtensions(NewSubscript(tc.opts...)))
+			testutil.DoTestCase(md, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    `x~a[~ and x~[a~`,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+}
+
+func TestTilde(t *testing.T) {
+	mdGFM := goldmark.New(
+		goldmark.WithExtensions(
+			extension.Table,
+			extension.Linkify,
+			extension.TaskList,
+			Tilde,
+		),
+	)
+	mdPandoc := goldmark.New(
+		goldmark.WithExtensions(
+			NewTilde(WithTildeMode(TildeModePandoc)),
+		),
+	)
+
+	testCases := []struct {
+		desc   string
+		md     string
+		gfm    string
+		pandoc string
+	}{
+		{
+			desc:   "Tilde: subscript and strikethrough",
+			md:     `H~2~O is ~~gone~~ now`,
+			gfm:    `<p>H<sub>2</sub>O is <del>gone</del> now</p>`,
+			pandoc: `<p>H<sub>2</sub>O is <del>gone</del> now</p>`,
+		},
+		{
+			desc:   "Tilde: strikethrough at beginning of line",
+			md:     `~~strike~~ at start`,
+			gfm:    `<p><del>strike</del> at start</p>`,
+			pandoc: `<p><del>strike</del> at start</p>`,
+		},
+		{
+			desc:   "Tilde: single tildes that cannot be a subscript",
+			md:     `~not sub~ here and a ~b~`,
+			gfm:    `<p><del>not sub</del> here and a <del>b</del></p>`,
+			pandoc: `<p>~not sub~ here and a ~b~</p>`,
+		},
+		{
+			desc:   "Tilde: subscript inside strikethrough",
+			md:     `H~2~O and ~~a~b~c~~`,
+			gfm:    `<p>H<sub>2</sub>O and <del>a<sub>b</sub>c</del></p>`,
+			pandoc: `<p>H<sub>2</sub>O and <del>a<sub>b</sub>c</del></p>`,
+		},
+		{
+			desc:   "Tilde: only runs of the same length are paired",
+			md:     `~~a~ b`,
+			gfm:    `<p>~~a~ b</p>`,
+			pandoc: `<p>~~a~ b</p>`,
+		},
+		{
+			desc:   "Tilde: strikethrough inside a word",
+			md:     `a~~b~~c`,
+			gfm:    `<p>a<del>b</del>c</p>`,
+			pandoc: `<p>a<del>b</del>c</p>`,
+		},
+		{
+			desc:   "Tilde: three or more tildes are literal",
+			md:     `x~~~y~~~`,
+			gfm:    `<p>x~~~y~~~</p>`,
+			pandoc: `<p>x~~~y~~~</p>`,
+		},
+		{
+			desc:   "Tilde: unclosed strikethrough",
+			md:     `~~open only`,
+			gfm:    `<p>~~open only</p>`,
+			pandoc: `<p>~~open only</p>`,
+		},
+		{
+			desc:   "Tilde: unclosed link label",
+			md:     `x~a[~ and x~[a~ and x~[a]~`,
+			gfm:    `<p>x<del>a[</del> and x<del>[a</del> and x<sub>[a]</sub></p>`,
+			pandoc: `<p>x~a[~ and x~[a~ and x<sub>[a]</sub></p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("GFM/"+tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(mdGFM, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.gfm,
+			}, t)
+		})
+		t.Run("Pandoc/"+tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(mdPandoc, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.pandoc,
+			}, t)
+		})
+	}
+}
+
+// testStrikethroughRenderer renders strikethroughs as <s> elements.
+type testStrikethroughRenderer struct{}
+
+func (testStrikethroughRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(extast.KindStrikethrough, func(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			_, _ = w.WriteString("<s>")
+		} else {
+			_, _ = w.WriteString("</s>")
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func TestTildeRendererPriority(t *testing.T) {
+	testCases := []struct {
+		desc string
+		opts []SubscriptOption
+		html string
+	}{
+		{
+			desc: "Tilde renderer priority: default priority wins over 200",
+			html: `<p>H<sub>2</sub>O is <del>gone</del></p>`,
+		},
+		{
+			desc: "Tilde renderer priority: priority 300 loses to 200",
+			opts: []SubscriptOption{WithRendererPriority(300)},
+			html: `<p>H<sub>2</sub>O is <s>gone</s></p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			mdTest := goldmark.New(
+				goldmark.WithExtensions(
+					NewTilde(tc.opts...),
+				),
+				goldmark.WithRendererOptions(
+					renderer.WithNodeRenderers(util.Prioritized(testStrikethroughRenderer{}, 200)),
+				),
+			)
+			testutil.DoTestCase(mdTest, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    `H~2~O is ~~gone~~`,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+}
+
+func TestSubscriptWithoutStrikethrough(t *testing.T) {
+	testCases := []TestCase{
+		{
+			desc: "Without strikethrough: subscript at beginning of line",
+			md:   `~2~O`,
+			html: `<p><sub>2</sub>O</p>`,
+		},
+		{
+			desc: "Without strikethrough: subscript after whitespace",
+			md:   `H ~2~ O`,
+			html: `<p>H <sub>2</sub> O</p>`,
+		},
+		{
+			desc: "Without strikethrough: invalid subscripts stay literal",
+			md:   `a ~b c~ d and ~~x~~`,
+			html: `<p>a ~b c~ d and ~~x~~</p>`,
+		},
+		{
+			desc: "Without strikethrough: subscripts in table cells",
+			md:   "| Formula | Name |\n| --- | --- |\n| ~2~H | x ~i~ |",
+			html: "<table>\n<thead>\n<tr>\n<th>Formula</th>\n<th>Name</th>\n</tr>\n</thead>\n" +
+				"<tbody>\n<tr>\n<td><sub>2</sub>H</td>\n<td>x <sub>i</sub></td>\n</tr>\n</tbody>\n</table>",
+		},
+	}
+
+	setups := map[string]goldmark.Markdown{
+		// Added before the table extension, so detection cannot happen in Extend
+		"detected": goldmark.New(
+			goldmark.WithExtensions(
+				NewSubscript(),
+				extension.Table,
+			),
+		),
+		"detected with whitespace flanking": goldmark.New(
+			goldmark.WithExtensions(
+				NewSubscript(WithWhitespaceFlanking()),
+				extension.Table,
+			),
+		),
+		"option": goldmark.New(
+			goldmark.WithExtensions(
+				extension.Table,
+				NewSubscript(WithStrikethrough(StrikethroughAbsent)),
+			),
+		),
+	}
+
+	for name, md := range setups {
+		for _, tc := range testCases {
+			t.Run(name+"/"+tc.desc, func(t *testing.T) {
+				testutil.DoTestCase(md, testutil.MarkdownTestCase{
+					Description: tc.desc,
+					Markdown:    tc.md,
+					Expected:    tc.html,
+				}, t)
+			})
+		}
+	}
+
+	// A strikethrough parser added after the subscript extension is still detected
+	mdStrike := goldmark.New(
+		goldmark.WithExtensions(
+			NewSubscript(),
+			extension.Strikethrough,
+		),
+	)
+	testutil.DoTestCase(mdStrike, testutil.MarkdownTestCase{
+		Description: "With strikethrough: subscript cannot start at beginning of line",
+		Markdown:    `~2~O`,
+		Expected:    `<p><del>2</del>O</p>`,
+	}, t)
+
+	// The option wins over detection
+	mdPresent := goldmark.New(
+		goldmark.WithExtensions(
+			NewSubscript(WithStrikethrough(StrikethroughPresent)),
+		),
+	)
+	testutil.DoTestCase(mdPresent, testutil.MarkdownTestCase{
+		Description: "With strikethrough option: subscript cannot start at beginning of line",
+		Markdown:    `~2~O`,
+		Expected:    `<p>~2~O</p>`,
+	}, t)
+}
+
+func TestSubscriptUnicodeSpaces(t *testing.T) {
+	mdTest := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			NewSubscript(),
+		),
+	)
+
+	testCases := []TestCase{
+		{
+			desc: "Unicode spaces: continuation bytes are not whitespace",
+			md:   "x~à~ y~Ņ~ X~α…~",
+			html: "<p>x<sub>à</sub> y<sub>Ņ</sub> X<sub>α…</sub></p>",
+		},
+		{
+			desc: "Unicode spaces: ideographic space",
+			md:   "x~a\u3000b~",
+			html: "<p>x<del>a\u3000b</del></p>",
+		},
+		{
+			desc: "Unicode spaces: em space",
+			md:   "x~a\u2003b~",
+			html: "<p>x<del>a\u2003b</del></p>",
+		},
+		{
+			desc: "Unicode spaces: no-break space",
+			md:   "x~a\u00a0b~",
+			html: "<p>x<del>a\u00a0b</del></p>",
+		},
+		{
+			desc: "Unicode spaces: no subscript after an ideographic space",
+			md:   "x\u3000~2~",
+			html: "<p>x\u3000<del>2</del></p>",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(mdTest, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+
+	mdPolicy := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			NewSubscript(WithSpacePolicy(func(r rune) bool {
+				return r != '\u00a0' && r != '\u3000' && unicode.IsSpace(r)
+			})),
+		),
+	)
+
+	policyCases := []TestCase{
+		{
+			desc: "Space policy: allowed spaces",
+			md:   "x~a\u00a0b~ and x~a\u3000b~",
+			html: "<p>x<sub>a\u00a0b</sub> and x<sub>a\u3000b</sub></p>",
+		},
+		{
+			desc: "Space policy: subscript after an allowed space",
+			md:   "x\u3000~2~",
+			html: "<p>x\u3000<sub>2</sub></p>",
+		},
+		{
+			desc: "Space policy: other spaces",
+			md:   "x~a\u2003b~",
+			html: "<p>x<del>a\u2003b</del></p>",
+		},
+	}
+
+	for _, tc := range policyCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(mdPolicy, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+}
+
+func TestSubscriptTildeRuns(t *testing.T) {
+	testCases := []TestCase{
+		{
+			desc: "Tilde runs: three or more tildes are literal",
+			md:   `x~~~y~~~ and a ~~~~ separator`,
+			html: `<p>x~~~y~~~ and a ~~~~ separator</p>`,
+		},
+		{
+			desc: "Tilde runs: subscripts around a run",
+			md:   `H~2~ ~~~~~ H~2~O`,
+			html: `<p>H<sub>2</sub> ~~~~~ H<sub>2</sub>O</p>`,
+		},
+		{
+			desc: "Tilde runs: strikethrough next to a run",
+			md:   `~~a~~ ~~~ ~~b~~`,
+			html: `<p><del>a</del> ~~~ <del>b</del></p>`,
+		},
+	}
+
+	setups := map[string]goldmark.Markdown{
+		"default":             goldmark.New(goldmark.WithExtensions(extension.GFM, NewSubscript())),
+		"whitespace flanking": goldmark.New(goldmark.WithExtensions(extension.GFM, NewSubscript(WithWhitespaceFlanking()))),
+		"tilde":               goldmark.New(goldmark.WithExtensions(Tilde)),
+	}
+
+	for name, md := range setups {
+		for _, tc := range testCases {
+			t.Run(name+"/"+tc.desc, func(t *testing.T) {
+				testutil.DoTestCase(md, testutil.MarkdownTestCase{
+					Description: tc.desc,
+					Markdown:    tc.md,
+					Expected:    tc.html,
+				}, t)
+			})
+		}
+	}
+}
+
+func TestSubscriptTriggerDoesNotAllocate(t *testing.T) {
+	p := NewSubscriptParser()
+	if n := testing.AllocsPerRun(100, func() { _ = p.Trigger() }); n != 0 {
+		t.Errorf("Trigger allocates %v times per call", n)
+	}
+}
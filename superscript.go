@@ -0,0 +1,249 @@
+package subscript
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// KindSuperscript is a NodeKind of the Superscript node.
+var KindSuperscript = ast.NewNodeKind("Superscript")
+
+// SuperscriptNode represents a superscript node in the AST.
+type SuperscriptNode struct {
+	ast.BaseInline
+}
+
+// Kind implements ast.Node.Kind.
+func (*SuperscriptNode) Kind() ast.NodeKind {
+	return KindSuperscript
+}
+
+// Dump implements ast.Node.Dump.
+func (n *SuperscriptNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// NewSuperscriptNode returns a new Superscript node.
+func NewSuperscriptNode() *SuperscriptNode {
+	return &SuperscriptNode{}
+}
+
+// superscriptParser implements parser.InlineParser for superscript syntax (x^2^).
+//
+// The content of a superscript follows the rules of subscript content: it must not be empty and must not
+// contain unescaped whitespace or carets. Superscripts do not compete with strikethrough, so they can start
+// anywhere, except right after an opening bracket, where the caret belongs to a footnote reference ("[^1]"), and
+// they cannot end with one ("mc^2[^1]").
+// The content is always emitted as raw text.
+type superscriptParser struct {
+	Config
+}
+
+// NewSuperscriptParser returns a new InlineParser that parses superscript expressions.
+func NewSuperscriptParser(opts ...SubscriptOption) parser.InlineParser {
+	return newSuperscriptParser(newConfig(opts))
+}
+
+func newSuperscriptParser(config Config) *superscriptParser {
+	return &superscriptParser{
+		Config: config,
+	}
+}
+
+// caretTrigger is returned by Trigger, so that Trigger does not allocate.
+var caretTrigger = []byte{'^'}
+
+// Trigger implements parser.InlineParser.Trigger.
+func (s *superscriptParser) Trigger() []byte {
+	return caretTrigger
+}
+
+// Parse implements parser.InlineParser.Parse.
+func (s *superscriptParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	before := block.PrecendingCharacter()
+	line, segment := block.PeekLine()
+
+	// A caret right after an opening bracket belongs to a footnote reference
+	if before == '[' {
+		return nil
+	}
+
+	// The same goes for the last caret of a run, unless the caret before it closed a superscript ("x^2^^3^")
+	if _, ok := parent.LastChild().(*SuperscriptNode); before == '^' && !ok {
+		return nil
+	}
+
+	// Check if we have at least one character after the caret, and that it is not another caret
+	if len(line) < 2 || line[1] == '^' {
+		return nil
+	}
+
+	// Look for the closing caret
+	end := bytes.IndexByte(line[1:], '^') + 1
+	if end == 0 {
+		return nil
+	}
+
+	// A closing caret right after an opening bracket starts a footnote reference ("mc^2[^1]") instead
+	content := line[1:end]
+	if content[len(content)-1] == '[' || !s.isValidContent(content) {
+		return nil
+	}
+
+	node := NewSuperscriptNode()
+	block.Advance(1)
+	contentSegment := segment.WithStart(segment.Start + 1)
+	s.appendContent(node, contentSegment.WithStop(segment.Start+end), content)
+	block.Advance(end)
+	return node
+}
+
+// isValidContent reports whether content can be the content of a superscript.
+// Content must not be empty and must not contain whitespace (unless escaped).
+func (s *superscriptParser) isValidContent(content []byte) bool {
+	if len(content) == 0 {
+		return false
+	}
+	for i := 0; i < len(content); {
+		r, n := utf8.DecodeRune(content[i:])
+		if s.isSpace(r) && !(s.EscapedSpaces && r == ' ' && isEscapedSpace(content, i)) {
+			return false
+		}
+		i += n
+	}
+	return true
+}
+
+// SuperscriptHTMLRenderer renders Superscript nodes to HTML <sup> elements.
+//
+// Each renderer owns a private copy of its attribute filter, so renderers of
+// different goldmark instances never share mutable state.
+type SuperscriptHTMLRenderer struct {
+	html.Config
+	attributeFilter util.BytesFilter
+}
+
+// NewSuperscriptHTMLRenderer returns a new SuperscriptHTMLRenderer with the given options.
+func NewSuperscriptHTMLRenderer(opts ...html.Option) renderer.NodeRenderer {
+	config := NewConfig()
+	config.HTMLOptions = opts
+	return newSuperscriptHTMLRenderer(config)
+}
+
+// NewSuperscriptHTMLRendererWithConfig returns a new SuperscriptHTMLRenderer with the settings of config,
+// such as its attribute filter and HTML options.
+func NewSuperscriptHTMLRendererWithConfig(config Config) renderer.NodeRenderer {
+	return newSuperscriptHTMLRenderer(config)
+}
+
+func newSuperscriptHTMLRenderer(config Config) *SuperscriptHTMLRenderer {
+	r := &SuperscriptHTMLRenderer{}
+	r.Config, r.attributeFilter = newHTMLConfig(config)
+	return r
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *SuperscriptHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindSuperscript, r.renderSuperscript)
+}
+
+func (r *SuperscriptHTMLRenderer) renderSuperscript(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		if n.Attributes() != nil {
+			_, _ = w.WriteString("<sup")
+			html.RenderAttributes(w, n, r.attributeFilter)
+			_ = w.WriteByte('>')
+		} else {
+			_, _ = w.WriteString("<sup>")
+		}
+	} else {
+		_, _ = w.WriteString("</sup>")
+	}
+	return ast.WalkContinue, nil
+}
+
+// superscript implements goldmark.Extender for the superscript extension.
+type superscript struct {
+	config Config
+}
+
+// Superscript is a pre-configured superscript extension instance.
+var Superscript = NewSuperscript()
+
+// NewSuperscript creates a new superscript extension with the given options.
+func NewSuperscript(opts ...SubscriptOption) *superscript {
+	return &superscript{
+		config: newConfig(opts),
+	}
+}
+
+// Extend implements goldmark.Extender by adding superscript parsing and rendering to the markdown processor.
+func (s *superscript) Extend(m goldmark.Markdown) {
+	extendSuperscript(m, s.config)
+}
+
+// extendSuperscript adds the superscript parser and renderer configured by config to m.
+func extendSuperscript(m goldmark.Markdown, config Config) {
+	m.Parser().AddOptions(parser.WithInlineParsers(
+		util.Prioritized(newSuperscriptParser(config), config.ParserPriority),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(newSuperscriptRenderer(config), config.RendererPriority),
+	))
+}
+
+// newSuperscriptRenderer returns the renderer for Superscript nodes selected by config.
+func newSuperscriptRenderer(config Config) renderer.NodeRenderer {
+	switch config.OutputFormat {
+	case OutputHTML:
+		return newSuperscriptHTMLRenderer(config)
+	case OutputLaTeX:
+		return newSuperscriptLaTeXRenderer(config)
+	}
+	panic("subscript: superscripts cannot be rendered with " + config.OutputFormat.String())
+}
+
+// scripts implements goldmark.Extender for subscripts and superscripts together.
+type scripts struct {
+	config Config
+}
+
+// Scripts is a pre-configured extension instance that enables both subscripts and superscripts.
+var Scripts = NewScripts()
+
+// NewScripts creates an extension that enables both subscripts (H~2~O) and superscripts (x^2^).
+// The options apply to both.
+func NewScripts(opts ...SubscriptOption) *scripts {
+	return &scripts{
+		config: newConfig(opts),
+	}
+}
+
+// Extend implements goldmark.Extender by adding subscript and superscript parsing and rendering to the markdown processor.
+func (s *scripts) Extend(m goldmark.Markdown) {
+	extendSubscript(m, s.config)
+	extendSuperscript(m, s.config)
+	if s.config.Stacked != StackNone {
+		m.Parser().AddOptions(parser.WithASTTransformers(
+			util.Prioritized(NewStackedScriptsTransformer(), s.config.ParserPriority),
+		))
+		m.Renderer().AddOptions(renderer.WithNodeRenderers(
+			util.Prioritized(newSubSuperscriptRenderer(s.config), s.config.RendererPriority),
+		))
+	}
+	if s.config.Stacked == StackMathML {
+		// runs after the formula transformers, which read the base as part of the text
+		m.Parser().AddOptions(parser.WithASTTransformers(
+			util.Prioritized(stackedBaseTransformer{}, s.config.ParserPriority+2000),
+		))
+	}
+}
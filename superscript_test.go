@@ -0,0 +1,192 @@
+package subscript
+
+import (
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/testutil"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+func TestSuperscript(t *testing.T) {
+	mdTest := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			extension.Footnote,
+			Scripts,
+		),
+	)
+
+	testCases := []TestCase{
+		{
+			desc: "Superscript: basic test",
+			md:   `x^2^`,
+			html: `<p>x<sup>2</sup></p>`,
+		},
+		{
+			desc: "Superscript: ion with subscript and charge",
+			md:   `SO~4~^2-^ and H~2~O`,
+			html: `<p>SO<sub>4</sub><sup>2-</sup> and H<sub>2</sub>O</p>`,
+		},
+		{
+			desc: "Superscript: at beginning of line and after whitespace",
+			md:   `^a^ and ^b^`,
+			html: `<p><sup>a</sup> and <sup>b</sup></p>`,
+		},
+		{
+			desc: "Superscript: no spaces",
+			md:   `x^a b^`,
+			html: `<p>x^a b^</p>`,
+		},
+		{
+			desc: "Superscript: escaped spaces",
+			md:   `x^a\ b^`,
+			html: `<p>x<sup>a b</sup></p>`,
+		},
+		{
+			desc: "Superscript: empty",
+			md:   `a^^b^^`,
+			html: `<p>a^^b^^</p>`,
+		},
+		{
+			desc: "Superscript: adjacent superscripts",
+			md:   `x^2^^3^`,
+			html: `<p>x<sup>2</sup><sup>3</sup></p>`,
+		},
+		{
+			desc: "Superscript: unclosed",
+			md:   `2^10 is 1024`,
+			html: `<p>2^10 is 1024</p>`,
+		},
+		{
+			desc: "Superscript: footnote reference",
+			md:   "Water[^1] is H~2~O and e = mc^2^.\n\n[^1]: x^2^",
+			html: `<p>Water<sup id="fnref:1"><a href="#fn:1" class="footnote-ref" role="doc-noteref">1</a></sup>` +
+				` is H<sub>2</sub>O and e = mc<sup>2</sup>.</p>
+<div class="footnotes" role="doc-endnotes">
+<hr>
+<ol>
+<li id="fn:1">
+<p>x<sup>2</sup>&#160;<a href="#fnref:1" class="footnote-backref" role="doc-backlink">&#x21a9;&#xfe0e;</a></p>
+</li>
+</ol>
+</div>`,
+		},
+		{
+			desc: "Superscript: footnote reference after an unclosed caret",
+			md:   "E=mc^2[^1] and x^a[^1]\n\n[^1]: Einstein",
+			html: `<p>E=mc^2<sup id="fnref:1"><a href="#fn:1" class="footnote-ref" role="doc-noteref">1</a></sup>` +
+				` and x^a<sup id="fnref1:1"><a href="#fn:1" class="footnote-ref" role="doc-noteref">1</a></sup></p>
+<div class="footnotes" role="doc-endnotes">
+<hr>
+<ol>
+<li id="fn:1">
+<p>Einstein&#160;<a href="#fnref:1" class="footnote-backref" role="doc-backlink">&#x21a9;&#xfe0e;</a>&#160;<a href="#fnref1:1" class="footnote-backref" role="doc-backlink">&#x21a9;&#xfe0e;</a></p>
+</li>
+</ol>
+</div>`,
+		},
+		{
+			desc: "Superscript: superscript before a footnote reference",
+			md:   "E=mc^2^[^1]\n\n[^1]: Einstein",
+			html: `<p>E=mc<sup>2</sup><sup id="fnref:1"><a href="#fn:1" class="footnote-ref" role="doc-noteref">1</a></sup></p>
+<div class="footnotes" role="doc-endnotes">
+<hr>
+<ol>
+<li id="fn:1">
+<p>Einstein&#160;<a href="#fnref:1" class="footnote-backref" role="doc-backlink">&#x21a9;&#xfe0e;</a></p>
+</li>
+</ol>
+</div>`,
+		},
+		{
+			desc: "Superscript: undefined footnote reference",
+			md:   `See [^note] and x^2^`,
+			html: `<p>See [^note] and x<sup>2</sup></p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(mdTest, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+}
+
+func TestSuperscriptOnly(t *testing.T) {
+	mdTest := goldmark.New(
+		goldmark.WithExtensions(
+			NewSuperscript(WithHTMLOptions(html.WithXHTML())),
+		),
+	)
+
+	testutil.DoTestCase(mdTest, testutil.MarkdownTestCase{
+		Description: "Superscript without subscript",
+		Markdown:    `x^2^ and H~2~O`,
+		Expected:    `<p>x<sup>2</sup> and H~2~O</p>`,
+	}, t)
+}
+
+// superscriptAttributeTransformer adds attributes to every Superscript node so attribute rendering can be tested.
+type superscriptAttributeTransformer struct{}
+
+func (superscriptAttributeTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering && n.Kind() == KindSuperscript {
+			n.SetAttributeString("class", []byte("exp"))
+			n.SetAttributeString("onclick", []byte("alert(1)"))
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func TestSuperscriptHTMLRendererConstructors(t *testing.T) {
+	config := NewConfig()
+	WithAttributeFilter(util.NewBytesFilter([]byte("onclick")))(&config)
+
+	testCases := []struct {
+		desc     string
+		renderer renderer.NodeRenderer
+		html     string
+	}{
+		{
+			desc:     "Superscript renderer constructors: HTML options",
+			renderer: NewSuperscriptHTMLRenderer(html.WithXHTML()),
+			html:     `<p>x<sup class="exp">2</sup></p>`,
+		},
+		{
+			desc:     "Superscript renderer constructors: config",
+			renderer: NewSuperscriptHTMLRendererWithConfig(config),
+			html:     `<p>x<sup onclick="alert(1)">2</sup></p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			mdTest := goldmark.New(
+				goldmark.WithParserOptions(
+					parser.WithInlineParsers(util.Prioritized(NewSuperscriptParser(), 100)),
+					parser.WithASTTransformers(util.Prioritized(superscriptAttributeTransformer{}, 100)),
+				),
+				goldmark.WithRendererOptions(
+					renderer.WithNodeRenderers(util.Prioritized(tc.renderer, 100)),
+				),
+			)
+			testutil.DoTestCase(mdTest, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    `x^2^`,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package subscript
+
+import (
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// SubscriptTypstRenderer renders Subscript nodes to Typst markup ("H~2~O" becomes "H#sub[2]O"), for a
+// goldmark renderer that produces Typst. The extension registers it with WithOutputFormat(OutputTypst).
+//
+// Typst markup characters in the content are escaped with a backslash. Content that is not plain text
+// (see WithInlineMarkdown) is left to the other renderers inside "#sub[...]".
+type SubscriptTypstRenderer struct{}
+
+// NewSubscriptTypstRenderer returns a new SubscriptTypstRenderer.
+func NewSubscriptTypstRenderer() renderer.NodeRenderer {
+	return &SubscriptTypstRenderer{}
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *SubscriptTypstRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindSubscript, r.renderSubscript)
+}
+
+func (r *SubscriptTypstRenderer) renderSubscript(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !isTextOnly(n) {
+		if entering {
+			_, _ = w.WriteString("#sub[")
+		} else {
+			_ = w.WriteByte(']')
+		}
+		return ast.WalkContinue, nil
+	}
+	if entering {
+		_, _ = w.WriteString("#sub[")
+		writeTypst(w, appendPlainText(nil, n, source))
+		_ = w.WriteByte(']')
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// writeTypst writes s for use in Typst markup, escaping markup characters.
+func writeTypst(w util.BufWriter, s []byte) {
+	for i, b := range s {
+		switch b {
+		case '\\', '*', '_', '`', '#', '[', ']', '$', '<', '>', '@', '~', '/', '\'', '"':
+			_ = w.WriteByte('\\')
+		case '-':
+			// "--" and "---" are dashes, "-?" is a soft hyphen
+			if i+1 < len(s) && (s[i+1] == '-' || s[i+1] == '?') {
+				_ = w.WriteByte('\\')
+			}
+		}
+		_ = w.WriteByte(b)
+	}
+}
@@ -0,0 +1,16 @@
+package subscript
+
+import (
+	"testing"
+)
+
+func TestSubscriptTypstRenderer(t *testing.T) {
+	testOutputFormat(t, NewSubscriptTypstRenderer(), []SubscriptOption{WithInlineMarkdown()}, []outputTestCase{
+		{`H~2~O and x~i+1~`, `H#sub[2]O and x#sub[i+1]`},
+		{`x~a_b#c~`, `x#sub[a\_b\#c]`},
+		{`x~[a]$@<b~`, `x#sub[\[a\]\$\@\<b]`},
+		{`x~a--b/c~`, `x#sub[a\--b\/c]`},
+		{`x~a\\b~`, `x#sub[a\\b]`},
+		{`x~*i*~`, `x#sub[*i*]`},
+	})
+}
@@ -0,0 +1,189 @@
+package subscript
+
+import (
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// unicodeSubscripts maps characters to their Unicode subscript forms.
+var unicodeSubscripts = map[rune]rune{
+	'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄', '5': '₅', '6': '₆', '7': '₇', '8': '₈', '9': '₉',
+	'+': '₊', '-': '₋', '−': '₋', '=': '₌', '(': '₍', ')': '₎',
+	'a': 'ₐ', 'e': 'ₑ', 'h': 'ₕ', 'i': 'ᵢ', 'j': 'ⱼ', 'k': 'ₖ', 'l': 'ₗ', 'm': 'ₘ', 'n': 'ₙ', 'o': 'ₒ',
+	'p': 'ₚ', 'r': 'ᵣ', 's': 'ₛ', 't': 'ₜ', 'u': 'ᵤ', 'v': 'ᵥ', 'x': 'ₓ', 'ə': 'ₔ',
+	'β': 'ᵦ', 'γ': 'ᵧ', 'ρ': 'ᵨ', 'φ': 'ᵩ', 'χ': 'ᵪ',
+	' ': ' ',
+}
+
+// unicodeSubscriptCharacters maps Unicode subscript characters back to the characters they stand for.
+var unicodeSubscriptCharacters = func() map[rune]rune {
+	m := make(map[rune]rune, len(unicodeSubscripts))
+	for r, sub := range unicodeSubscripts {
+		if r != sub && r != '−' {
+			m[sub] = r
+		}
+	}
+	return m
+}()
+
+// UnicodeSubscript returns s written with Unicode subscript characters ("12" → "₁₂"), or false if a
+// character of s has no subscript form.
+func UnicodeSubscript(s string) (string, bool) {
+	b, ok := appendUnicodeSubscript(nil, []byte(s))
+	return string(b), ok
+}
+
+// appendUnicodeSubscript appends s written with Unicode subscript characters to buf. If a character has
+// no subscript form, it returns buf unchanged and false.
+func appendUnicodeSubscript(buf []byte, s []byte) ([]byte, bool) {
+	n := len(buf)
+	for len(s) > 0 {
+		r, size := utf8.DecodeRune(s)
+		sub, ok := unicodeSubscripts[r]
+		if !ok {
+			return buf[:n], false
+		}
+		buf = utf8.AppendRune(buf, sub)
+		s = s[size:]
+	}
+	return buf, true
+}
+
+// UnicodeFallback returns the text written for the content of a subscript that has no Unicode form.
+type UnicodeFallback func(content []byte) []byte
+
+// FallbackLaTeX writes a single character as "_x" and longer content as "_{xyz}". This is the default.
+func FallbackLaTeX(content []byte) []byte {
+	if utf8.RuneCount(content) == 1 {
+		return append([]byte{'_'}, content...)
+	}
+	return FallbackBraces(content)
+}
+
+// FallbackBraces always writes "_{xyz}".
+func FallbackBraces(content []byte) []byte {
+	return append(append([]byte("_{"), content...), '}')
+}
+
+// SubscriptUnicodeRenderer renders Subscript nodes as Unicode subscript characters ("H~2~O" → "H₂O"),
+// for output that cannot use <sub>: plain-text email, <title> elements, chat messages and terminals.
+//
+// Subscripts with a character that has no subscript form are written with the fallback instead. The
+// output is escaped for HTML.
+type SubscriptUnicodeRenderer struct {
+	fallback UnicodeFallback
+}
+
+// NewSubscriptUnicodeRenderer returns a new SubscriptUnicodeRenderer with the given options.
+//
+// The fallback is set with WithUnicodeSubscripts.
+func NewSubscriptUnicodeRenderer(opts ...SubscriptOption) renderer.NodeRenderer {
+	return newSubscriptUnicodeRenderer(newConfig(opts))
+}
+
+func newSubscriptUnicodeRenderer(config Config) *SubscriptUnicodeRenderer {
+	r := &SubscriptUnicodeRenderer{
+		fallback: config.UnicodeFallback,
+	}
+	if r.fallback == nil {
+		r.fallback = FallbackLaTeX
+	}
+	return r
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *SubscriptUnicodeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindSubscript, r.renderSubscript)
+}
+
+func (r *SubscriptUnicodeRenderer) renderSubscript(
+	w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	content := appendPlainText(nil, n, source)
+	if sub, ok := appendUnicodeSubscript(nil, content); ok {
+		_, _ = w.Write(sub)
+	} else {
+		_, _ = w.Write(util.EscapeHTML(r.fallback(content)))
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// unicodeSubscriptTransformer replaces runs of Unicode subscript characters by Subscript nodes.
+type unicodeSubscriptTransformer struct{}
+
+// NewUnicodeSubscriptTransformer returns an ASTTransformer that replaces runs of Unicode subscript
+// characters in text ("H₂O") by Subscript nodes holding the characters they stand for, so "H₂O" and
+// "H~2~O" are parsed the same. Text in code spans and raw text are left alone.
+func NewUnicodeSubscriptTransformer() parser.ASTTransformer {
+	return unicodeSubscriptTransformer{}
+}
+
+// Transform implements parser.ASTTransformer.
+func (unicodeSubscriptTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	var texts []*ast.Text
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.CodeSpan:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			if !n.IsRaw() {
+				texts = append(texts, n)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	for _, t := range texts {
+		splitUnicodeSubscripts(t, source)
+	}
+}
+
+// splitUnicodeSubscripts splits t around its runs of Unicode subscript characters and replaces the runs
+// by Subscript nodes.
+func splitUnicodeSubscripts(t *ast.Text, source []byte) {
+	parent := t.Parent()
+	value := t.Segment.Value(source)
+	start := 0 // start of the text before the next run
+	for i := 0; i < len(value); {
+		r, size := utf8.DecodeRune(value[i:])
+		if _, ok := unicodeSubscriptCharacters[r]; !ok {
+			i += size
+			continue
+		}
+		var content []byte
+		j := i
+		for j < len(value) {
+			r, size := utf8.DecodeRune(value[j:])
+			c, ok := unicodeSubscriptCharacters[r]
+			if !ok {
+				break
+			}
+			content = utf8.AppendRune(content, c)
+			j += size
+		}
+		if i > start {
+			parent.InsertBefore(parent, t, ast.NewTextSegment(text.NewSegment(t.Segment.Start+start, t.Segment.Start+i)))
+		}
+		node := NewSubscriptNode()
+		node.AppendChild(node, ast.NewString(content))
+		parent.InsertBefore(parent, t, node)
+		start, i = j, j
+	}
+	if start == 0 {
+		return
+	}
+	t.Segment = t.Segment.WithStart(t.Segment.Start + start)
+	if t.Segment.IsEmpty() && !t.SoftLineBreak() && !t.HardLineBreak() {
+		parent.RemoveChild(parent, t)
+	}
+}
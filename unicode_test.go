@@ -0,0 +1,134 @@
+package subscript
+
+import (
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/testutil"
+)
+
+func TestUnicodeSubscripts(t *testing.T) {
+	mdUnicode := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			NewSubscript(WithUnicodeSubscripts(nil)),
+		),
+	)
+
+	testCases := []TestCase{
+		{
+			desc: "Unicode: digits",
+			md:   "H~2~O and C~6~H~12~O~6~",
+			html: `<p>H₂O and C₆H₁₂O₆</p>`,
+		},
+		{
+			desc: "Unicode: letters and operators",
+			md:   "x~i+1~, T~max~ and x~(n-1)~",
+			html: `<p>xᵢ₊₁, Tₘₐₓ and x₍ₙ₋₁₎</p>`,
+		},
+		{
+			desc: "Unicode: escaped spaces",
+			md:   `T~max\ value~`,
+			html: `<p>Tₘₐₓ ᵥₐₗᵤₑ</p>`,
+		},
+		{
+			desc: "Unicode: single character fallback",
+			md:   "x~b~",
+			html: `<p>x_b</p>`,
+		},
+		{
+			desc: "Unicode: fallback for the whole subscript",
+			md:   "x~ab~ and x~a<b~",
+			html: `<p>x_{ab} and x_{a&lt;b}</p>`,
+		},
+		{
+			desc: "Unicode: escapes in the fallback",
+			md:   `x~a\\\ b~ and x~a&amp;b~`,
+			html: `<p>x_{a\ b} and x_{a&amp;b}</p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(mdUnicode, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+
+	mdBraces := goldmark.New(
+		goldmark.WithExtensions(
+			NewSubscript(WithUnicodeSubscripts(FallbackBraces)),
+		),
+	)
+	testutil.DoTestCase(mdBraces, testutil.MarkdownTestCase{
+		Description: "Unicode: braces fallback",
+		Markdown:    "x~b~ and x~2~",
+		Expected:    `<p>x_{b} and x₂</p>`,
+	}, t)
+}
+
+func TestUnicodeSubscript(t *testing.T) {
+	testCases := []struct {
+		in  string
+		out string
+		ok  bool
+	}{
+		{"12", "₁₂", true},
+		{"n+1", "ₙ₊₁", true},
+		{"β", "ᵦ", true},
+		{"b", "", false},
+		{"2b", "", false},
+	}
+	for _, tc := range testCases {
+		out, ok := UnicodeSubscript(tc.in)
+		if out != tc.out || ok != tc.ok {
+			t.Errorf("UnicodeSubscript(%q) = %q, %v; want %q, %v", tc.in, out, ok, tc.out, tc.ok)
+		}
+	}
+}
+
+func TestUnicodeInput(t *testing.T) {
+	mdTest := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			NewSubscript(WithUnicodeInput()),
+		),
+	)
+
+	testCases := []TestCase{
+		{
+			desc: "Unicode input: digits",
+			md:   "H₂O and C₆H₁₂O₆",
+			html: `<p>H<sub>2</sub>O and C<sub>6</sub>H<sub>12</sub>O<sub>6</sub></p>`,
+		},
+		{
+			desc: "Unicode input: letters and operators",
+			md:   "xᵢ₊₁ and Tₘₐₓ",
+			html: `<p>x<sub>i+1</sub> and T<sub>max</sub></p>`,
+		},
+		{
+			desc: "Unicode input: both spellings",
+			md:   "H₂O and H~2~O\nₙ",
+			html: "<p>H<sub>2</sub>O and H<sub>2</sub>O\n<sub>n</sub></p>",
+		},
+		{
+			desc: "Unicode input: code spans are untouched",
+			md:   "`H₂O`",
+			html: `<p><code>H₂O</code></p>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			testutil.DoTestCase(mdTest, testutil.MarkdownTestCase{
+				Description: tc.desc,
+				Markdown:    tc.md,
+				Expected:    tc.html,
+			}, t)
+		})
+	}
+}
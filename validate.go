@@ -0,0 +1,282 @@
+package subscript
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Diagnostic is a problem found in a chemical formula by the formula validator.
+type Diagnostic struct {
+	// Segment is the position of the offending text in the source.
+	Segment text.Segment
+
+	// Line and Column are the 1-based line and column (in runes) of Segment.Start.
+	Line, Column int
+
+	// Message describes the problem.
+	Message string
+
+	// Suggestion is a replacement for the text at Segment, or empty if there is none.
+	Suggestion string
+}
+
+// String formats the diagnostic as "line:column: message (did you mean "suggestion"?)".
+func (d Diagnostic) String() string {
+	if d.Suggestion == "" {
+		return fmt.Sprintf("%d:%d: %s", d.Line, d.Column, d.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s (did you mean %q?)", d.Line, d.Column, d.Message, d.Suggestion)
+}
+
+var diagnosticsKey = parser.NewContextKey()
+
+// Diagnostics returns the diagnostics reported by the formula validator while parsing with pc.
+//
+// Pass the context to Convert with parser.WithContext to read them afterwards:
+//
+//	pc := parser.NewContext()
+//	err := md.Convert(source, &buf, parser.WithContext(pc))
+//	for _, d := range subscript.Diagnostics(pc) {
+//		log.Print(d)
+//	}
+func Diagnostics(pc parser.Context) []Diagnostic {
+	diagnostics, _ := pc.Get(diagnosticsKey).([]Diagnostic)
+	return diagnostics
+}
+
+// formulaValidator reports diagnostics for chemical formulas written with subscripts.
+type formulaValidator struct{}
+
+// NewFormulaValidator returns an ASTTransformer that checks runs of text and subscripts that look like
+// chemical formulas ("C~6~H~12~O~6~"): element symbols must be in the periodic table and subscripts must
+// be numbers. Problems are reported as diagnostics, read with Diagnostics; the document is not changed.
+//
+// A run looks like a formula when it starts with an upper case letter, optionally after a coefficient or
+// an opening bracket, and at least one of its subscripts is a number, so "T~max~" and "x~1~" are skipped.
+func NewFormulaValidator() parser.ASTTransformer {
+	return formulaValidator{}
+}
+
+// formulaPart is a piece of a formula run: text written around the subscripts, or a subscript.
+type formulaPart struct {
+	value     []byte
+	start     int      // position of value in the source
+	node      ast.Node // the text or subscript node the part was taken from
+	subscript bool
+}
+
+// Transform implements parser.ASTTransformer.
+func (formulaValidator) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	diagnostics := Diagnostics(pc)
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if _, ok := n.(*FormulaNode); ok {
+			return ast.WalkSkipChildren, nil
+		}
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			if formulaSubscript(c) == nil {
+				continue
+			}
+			var parts []formulaPart
+			parts, c = formulaRun(c, source)
+			if looksLikeFormula(parts) {
+				diagnostics = validateFormula(diagnostics, parts, source)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	if len(diagnostics) > 0 {
+		pc.Set(diagnosticsKey, diagnostics)
+	}
+}
+
+// formulaSubscript returns the subscript n stands for in a formula run, or nil.
+func formulaSubscript(n ast.Node) *Node {
+	switch n := n.(type) {
+	case *Node:
+		return n
+	case *SubSuperscriptNode:
+		return n.Subscript()
+	}
+	return nil
+}
+
+// formulaRun collects the run of text and subscripts around the subscript first: the formula characters
+// directly before it, the subscripts separated only by formula characters and the formula characters
+// directly after the last one, up to a lower case letter that does not follow an upper case one. It returns
+// the parts and the last subscript of the run.
+func formulaRun(first ast.Node, source []byte) ([]formulaPart, ast.Node) {
+	var parts []formulaPart
+	if t, ok := first.PreviousSibling().(*ast.Text); ok {
+		value := t.Segment.Value(source)
+		i := len(value)
+		for i > 0 && isFormulaChar(value[i-1]) {
+			i--
+		}
+		if i < len(value) {
+			parts = append(parts, formulaPart{value: value[i:], start: t.Segment.Start + i, node: t})
+		}
+	}
+	last := first
+	for {
+		part := subscriptPart(formulaSubscript(last), source)
+		part.node = last
+		parts = append(parts, part)
+		t, ok := last.NextSibling().(*ast.Text)
+		if !ok {
+			break
+		}
+		value := t.Segment.Value(source)
+		i := 0
+		for i < len(value) && isFormulaChar(value[i]) {
+			i++
+		}
+		next := t.NextSibling()
+		if i == len(value) && !t.SoftLineBreak() && !t.HardLineBreak() && formulaSubscript(next) != nil {
+			parts = append(parts, formulaPart{value: value, start: t.Segment.Start, node: t})
+			last = next
+			continue
+		}
+		// a lower case letter that does not follow an element symbol starts a word ("CO~2~s are gases")
+		for j := 0; j < i; j++ {
+			if value[j] >= 'a' && value[j] <= 'z' && (j == 0 || value[j-1] < 'A' || value[j-1] > 'Z') {
+				i = j
+			}
+		}
+		if i > 0 {
+			parts = append(parts, formulaPart{value: value[:i], start: t.Segment.Start, node: t})
+		}
+		break
+	}
+	return parts, last
+}
+
+// subscriptPart returns the formula part for the subscript n.
+func subscriptPart(n *Node, source []byte) formulaPart {
+	part := formulaPart{value: appendNodeText(nil, n, source), start: -1, subscript: true}
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if t, ok := c.(*ast.Text); ok && entering {
+			part.start = t.Segment.Start
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return part
+}
+
+// isFormulaChar reports whether c can be written in a formula outside subscripts.
+func isFormulaChar(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || isDigit(c) ||
+		c == '(' || c == ')' || c == '[' || c == ']'
+}
+
+// looksLikeFormula reports whether the run starts with an upper case letter, after an optional
+// coefficient or opening bracket, and has a numeric subscript.
+func looksLikeFormula(parts []formulaPart) bool {
+	if len(parts) == 0 || parts[0].subscript {
+		return false
+	}
+	head := bytes.TrimLeft(parts[0].value, "0123456789([")
+	if len(head) == 0 || head[0] < 'A' || head[0] > 'Z' {
+		return false
+	}
+	for _, part := range parts {
+		if part.subscript && isNumber(part.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNumber(s []byte) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, c := range s {
+		if !isDigit(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateFormula appends the problems found in the formula run to diagnostics.
+func validateFormula(diagnostics []Diagnostic, parts []formulaPart, source []byte) []Diagnostic {
+	report := func(start int, value []byte, message, suggestion string) {
+		if start < 0 {
+			return
+		}
+		line, column := position(source, start)
+		diagnostics = append(diagnostics, Diagnostic{
+			Segment:    text.NewSegment(start, start+len(value)),
+			Line:       line,
+			Column:     column,
+			Message:    message,
+			Suggestion: suggestion,
+		})
+	}
+
+	for p, part := range parts {
+		value := part.value
+		if part.subscript {
+			if !isNumber(value) {
+				report(part.start, value, fmt.Sprintf("subscript %q is not a number", value), "")
+			}
+			continue
+		}
+		for i := 0; i < len(value); {
+			c := value[i]
+			switch {
+			case c >= 'A' && c <= 'Z':
+				j := i + 1
+				if j < len(value) && value[j] >= 'a' && value[j] <= 'z' {
+					j++
+				}
+				if lookupElement(value[i:j]) == nil {
+					report(part.start+i, value[i:j], fmt.Sprintf("unknown element symbol %q", value[i:j]), "")
+				}
+				i = j
+			case c >= 'a' && c <= 'z':
+				suggestion := ""
+				if lookupElement([]byte{c - 'a' + 'A'}) != nil {
+					suggestion = string(c - 'a' + 'A')
+				}
+				report(part.start+i, value[i:i+1],
+					fmt.Sprintf("element symbol %q must start with an upper case letter", value[i:i+1]), suggestion)
+				i++
+			case isDigit(c):
+				j := i + 1
+				for j < len(value) && isDigit(value[j]) {
+					j++
+				}
+				switch {
+				case p == 0 && bytes.IndexFunc(value[:i], func(r rune) bool { return r != '(' && r != '[' }) < 0:
+					// a coefficient ("2H~2~O")
+				case c == '0' && j == i+1:
+					report(part.start+i, value[i:j], "digit 0 where an element symbol is expected", "O")
+				default:
+					report(part.start+i, value[i:j], fmt.Sprintf("count %s is not written as a subscript", value[i:j]),
+						"~"+string(value[i:j])+"~")
+				}
+				i = j
+			default:
+				i++
+			}
+		}
+	}
+	return diagnostics
+}
+
+// position returns the 1-based line and column (in runes) of source[offset].
+func position(source []byte, offset int) (line, column int) {
+	lineStart := bytes.LastIndexByte(source[:offset], '\n') + 1
+	return bytes.Count(source[:lineStart], []byte{'\n'}) + 1, utf8.RuneCount(source[lineStart:offset]) + 1
+}
@@ -0,0 +1,106 @@
+package subscript
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+)
+
+func TestFormulaValidation(t *testing.T) {
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			NewScripts(WithFormulaValidation(), WithStackedScripts(StackHTML)),
+		),
+	)
+
+	testCases := []struct {
+		desc        string
+		md          string
+		diagnostics []string
+	}{
+		{
+			desc:        "Validation: zero instead of O",
+			md:          "Glucose is C~6~H~12~0~6~.",
+			diagnostics: []string{`1:21: digit 0 where an element symbol is expected (did you mean "O"?)`},
+		},
+		{
+			desc: "Validation: valid formulas",
+			md:   "H~2~O, 2H~2~O, Ca~3~(PO~4~)~2~, [Fe(CN)~6~]~3~ and SO~4~^2-^",
+		},
+		{
+			desc: "Validation: runs that are not formulas are skipped",
+			md:   "T~max~, x~1~, a~n~ and H~i~",
+		},
+		{
+			desc: "Validation: a word after a formula is not part of it",
+			md:   "CO~2~s are gases, and so is H~2~O's vapour; SO~4~ions",
+		},
+		{
+			desc:        "Validation: unknown element",
+			md:          "Xy~2~O",
+			diagnostics: []string{`1:1: unknown element symbol "Xy"`},
+		},
+		{
+			desc:        "Validation: lower case element",
+			md:          "C~6~h~12~O~6~",
+			diagnostics: []string{`1:5: element symbol "h" must start with an upper case letter (did you mean "H"?)`},
+		},
+		{
+			desc:        "Validation: count outside a subscript",
+			md:          "C6H~12~O~6~",
+			diagnostics: []string{`1:2: count 6 is not written as a subscript (did you mean "~6~"?)`},
+		},
+		{
+			desc:        "Validation: non-numeric subscript",
+			md:          "text\n\nC~6~H~x~",
+			diagnostics: []string{`3:7: subscript "x" is not a number`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			pc := parser.NewContext()
+			var buf bytes.Buffer
+			if err := md.Convert([]byte(tc.md), &buf, parser.WithContext(pc)); err != nil {
+				t.Fatal(err)
+			}
+			diagnostics := Diagnostics(pc)
+			if len(diagnostics) != len(tc.diagnostics) {
+				t.Fatalf("got diagnostics %v, want %q", diagnostics, tc.diagnostics)
+			}
+			for i, d := range diagnostics {
+				if got := d.String(); got != tc.diagnostics[i] {
+					t.Errorf("diagnostic %d = %q, want %q", i, got, tc.diagnostics[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFormulaValidationSegment(t *testing.T) {
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			NewSubscript(WithFormulaValidation()),
+		),
+	)
+	source := []byte("C~6~H~12~0~6~")
+	pc := parser.NewContext()
+	var buf bytes.Buffer
+	if err := md.Convert(source, &buf, parser.WithContext(pc)); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "<p>C<sub>6</sub>H<sub>12</sub>0<sub>6</sub></p>\n" {
+		t.Errorf("validation changed the output: %q", got)
+	}
+	diagnostics := Diagnostics(pc)
+	if len(diagnostics) != 1 {
+		t.Fatalf("got %d diagnostics, want 1", len(diagnostics))
+	}
+	if got := string(diagnostics[0].Segment.Value(source)); got != "0" {
+		t.Errorf("Segment = %q, want %q", got, "0")
+	}
+}